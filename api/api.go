@@ -3,14 +3,32 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"strconv"
 	"sync"
-
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/drain"
+	"github.com/OmGuptaIND/env"
+	"github.com/OmGuptaIND/health"
+	"github.com/OmGuptaIND/hls"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
 	"github.com/OmGuptaIND/pipeline"
+	"github.com/OmGuptaIND/postprocess"
+	"github.com/OmGuptaIND/quality"
+	"github.com/OmGuptaIND/queue"
+	"github.com/OmGuptaIND/scheduler"
 	"github.com/OmGuptaIND/store"
+	"github.com/OmGuptaIND/webhook"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // ApiServerOptions defines the configuration options for the ApiServer.
@@ -25,6 +43,9 @@ type ApiServer struct {
 	app  *fiber.App
 	opts ApiServerOptions
 	done chan bool
+	log  *slog.Logger
+
+	startedAt time.Time
 }
 
 // NewApiServer initializes a new API server with the specified options.
@@ -38,13 +59,45 @@ func NewApiServer(ctx context.Context, opts ApiServerOptions) *ApiServer {
 		app:  app,
 		opts: opts,
 		done: make(chan bool, 1),
+		log:  logger.Component("api"),
+
+		startedAt: time.Now(),
 	}
 
 	app.Get("/ping", apiServer.pingHandler)
+	app.Get("/healthz", apiServer.healthHandler)
+	app.Get("/readyz", apiServer.readyHandler)
+	app.Get("/metrics", metricsHandler)
+	app.Post("/admin/drain", apiServer.drainHandler)
 	app.Post("/start-recording", apiServer.startRecording)
 	app.Patch("/stop-recording", apiServer.stopRecording)
+	app.Get("/recordings", apiServer.listRecordings)
+	app.Get("/recordings/:id", apiServer.getRecording)
+	app.Get("/recordings/:id/logs", apiServer.getRecordingLogs)
+	app.Get("/recordings/:id/postprocess", apiServer.getRecordingPostprocess)
+	app.Post("/recordings/:id/actions", apiServer.runRecordingActions)
+	app.Get("/recordings/:id/screenshot", apiServer.getRecordingScreenshot)
+	app.Get("/queue/:id", apiServer.getQueueEntry)
+	app.Delete("/queue/:id", apiServer.cancelQueueEntry)
+	app.Post("/schedules", apiServer.createSchedule)
+	app.Get("/schedules", apiServer.listSchedules)
+	app.Get("/schedules/:id", apiServer.getSchedule)
+	app.Put("/schedules/:id", apiServer.updateSchedule)
+	app.Delete("/schedules/:id", apiServer.deleteSchedule)
+	app.Get("/events", apiServer.streamEvents)
+	app.Get("/events/ws", apiServer.streamEventsWebSocket)
+	app.Get("/webhooks/deliveries", apiServer.listDeliveries)
+	app.Get("/webhooks/deliveries/:id", apiServer.getDelivery)
 	app.Use(apiServer.notFoundHandler)
 
+	if q := queue.GetQueue(&ctx); q != nil {
+		q.SetLauncher(apiServer.launchQueued)
+	}
+
+	if s := scheduler.GetScheduler(&ctx); s != nil {
+		s.SetLauncher(apiServer.launchScheduled)
+	}
+
 	return apiServer
 }
 
@@ -58,7 +111,66 @@ func (a *ApiServer) pingHandler(c fiber.Ctx) error {
 	return c.SendString("pong")
 }
 
+// healthHandler reports the process is alive, it doesn't look at dependencies, see readyHandler.
+func (a *ApiServer) healthHandler(c fiber.Ctx) error {
+	return c.JSON(HealthResponse{
+		Status: "ok",
+		Uptime: time.Since(a.startedAt).Round(time.Second).String(),
+	})
+}
+
+// readyHandler reports whether the node accepts new pipelines, with the outcome of each dependency check.
+func (a *ApiServer) readyHandler(c fiber.Ctx) error {
+	var report health.Report
+
+	if checker := health.GetChecker(&a.ctx); checker != nil {
+		report = checker.Run(a.ctx)
+	}
+
+	switch {
+	case drain.GetDrainer(&a.ctx).Draining():
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ReadyResponse{
+			Status: "draining",
+			Checks: report.Checks,
+		})
+	case report.Checks != nil && !report.Ready:
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ReadyResponse{
+			Status:  "not_ready",
+			Failing: report.Failing(),
+			Checks:  report.Checks,
+		})
+	}
+
+	return c.JSON(ReadyResponse{
+		Status: "ready",
+		Checks: report.Checks,
+	})
+}
+
+// metricsHandler serves the node metrics in the Prometheus text format.
+var metricsHandler = adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+// drainHandler puts the node in drain mode.
+func (a *ApiServer) drainHandler(c fiber.Ctx) error {
+	d := drain.GetDrainer(&a.ctx)
+
+	if d == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Drain is not available")
+	}
+
+	d.Start()
+
+	return c.Status(fiber.StatusAccepted).JSON(DrainResponse{
+		Status:    "draining",
+		Pipelines: store.GetStore(&a.ctx).Count(),
+	})
+}
+
 func (a *ApiServer) startRecording(c fiber.Ctx) error {
+	if drain.GetDrainer(&a.ctx).Draining() {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Node is draining, not accepting new pipelines")
+	}
+
 	var req StartRecordingRequest
 
 	err := json.Unmarshal(c.Body(), &req)
@@ -67,23 +179,30 @@ func (a *ApiServer) startRecording(c fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request payload")
 	}
 
-	opts := &pipeline.NewPipelineOptions{
-		RecordUrl: req.RecordUrl,
-		StreamUrl: req.StreamUrl,
+	if err := req.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	p, err := pipeline.NewPipeline(a.ctx, opts)
+	if req.Queue {
+		return a.queueRecording(c, req)
+	}
 
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to start recording pipeline")
+	if !a.reserveSlot() {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Node is at capacity, retry later or set queue to true")
 	}
 
-	if err := p.Start(); err != nil {
-		log.Println("Error Occured Starting Pipeline", err)
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to start recording pipeline")
+	// A drain that began since the check above has already counted the reserved slot, or sees it now.
+	if drain.GetDrainer(&a.ctx).Draining() {
+		store.GetStore(&a.ctx).Release()
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Node is draining, not accepting new pipelines")
 	}
 
-	store.GetStore(&a.ctx).AddPipeLine(p.ID, p)
+	p, err := a.launchPipeline(req)
+
+	if err != nil {
+		a.log.Error("Error Occured Starting Pipeline", "error", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to start recording pipeline")
+	}
 
 	return c.JSON(StartRecordingResponse{
 		Status: "Recording Pipeline started",
@@ -91,8 +210,227 @@ func (a *ApiServer) startRecording(c fiber.Ctx) error {
 	})
 }
 
+// queueRecording places the start request on the start queue.
+func (a *ApiServer) queueRecording(c fiber.Ctx, req StartRecordingRequest) error {
+	q := queue.GetQueue(&a.ctx)
+
+	if q == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Start queue is not available")
+	}
+
+	payload, err := json.Marshal(req)
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request payload")
+	}
+
+	entry, err := q.Push(payload, req.Priority)
+
+	if err != nil {
+		a.log.Error("Error Occured Queueing Pipeline", "error", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to queue recording pipeline")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(StartRecordingResponse{
+		Status: "Recording Pipeline queued",
+		Id:     entry.ID,
+	})
+}
+
+// launchPipeline creates and starts a new pipeline, adding it to the store.
+// The caller has reserved a slot for it, which is released once the pipeline is in the store or failed to start.
+func (a *ApiServer) launchPipeline(req StartRecordingRequest) (*pipeline.Pipeline, error) {
+	appStore := store.GetStore(&a.ctx)
+	defer appStore.Release()
+
+	actions, err := toActions(req.Actions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hlsOpts, err := req.Output.hls(env.GetOutputFormat(), hls.Options{
+		SegmentType:     hls.SegmentType(env.GetHLSSegmentType()),
+		SegmentDuration: env.GetHLSSegmentDuration(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &pipeline.NewPipelineOptions{
+		RecordUrl:   req.RecordUrl,
+		StreamUrl:   req.StreamUrl,
+		MaxDuration: req.maxDuration(env.GetMaxRecordingDuration()),
+		QualityChecks: req.qualityChecks(quality.Thresholds{
+			SilenceAfter: env.GetQualitySilenceAfter(),
+			BlackAfter:   env.GetQualityBlackAfter(),
+			FreezeAfter:  env.GetQualityFreezeAfter(),
+			Fail:         env.GetQualityFail(),
+		}),
+		Watchdog: display.WatchdogOptions{
+			HeartbeatInterval: env.GetBrowserHeartbeatInterval(),
+			HeartbeatTimeout:  env.GetBrowserHeartbeatTimeout(),
+			FreezeAfter:       env.GetBrowserFreezeAfter(),
+		},
+		Ready:     req.Ready.condition(),
+		End:       req.End.condition(),
+		Actions:   actions,
+		Session:   req.session(),
+		Emulation: req.Emulation.emulation(),
+		Proxy:     req.Proxy.proxy(),
+		Network:   req.Network.rules(),
+		RecordHAR: env.GetBrowserHAR(),
+		HLS:       hlsOpts,
+	}
+
+	if req.StopAt != nil {
+		// Queued requests can sit around long enough for stop_at to pass.
+		if !req.StopAt.After(time.Now()) {
+			return nil, fmt.Errorf("stop_at %s has already passed", req.StopAt.UTC())
+		}
+
+		opts.StopAt = *req.StopAt
+	}
+
+	p, err := pipeline.NewPipeline(a.ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.OnStop = a.onPipelineStopped
+
+	if n := webhook.GetNotifier(&a.ctx); n != nil && req.WebhookUrl != "" {
+		secret := req.WebhookSecret
+
+		if secret == "" {
+			secret = env.GetWebhookSecret()
+		}
+
+		n.Register(p.ID, webhook.Target{
+			URL:    req.WebhookUrl,
+			Secret: secret,
+		})
+	}
+
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+
+	appStore.AddPipeLine(p.ID, p)
+
+	return p, nil
+}
+
+// onPipelineStopped moves a stopped pipeline out of the running set and frees its slot.
+func (a *ApiServer) onPipelineStopped(p *pipeline.Pipeline) {
+	status := p.Status()
+
+	a.log.Info("Pipeline stopped", logger.PipelineKey, p.ID, "reason", status.StopReason)
+
+	appStore := store.GetStore(&a.ctx)
+	appStore.RemovePipeline(p.ID)
+	appStore.AddFinished(status)
+
+	// Post-processing works on MP4 recordings, an HLS playlist is left as it is.
+	if status.RecordingUrl != "" && p.HLS == nil {
+		postprocess.GetProcessor(&a.ctx).Submit(postprocess.Recording{
+			ID:        p.ID,
+			ObjectKey: p.ObjectKey(),
+		}, func(artifacts map[string]string) {
+			appStore.AddArtifacts(p.ID, artifacts)
+		})
+	}
+
+	if q := queue.GetQueue(&a.ctx); q != nil {
+		q.Notify()
+	}
+}
+
+// launchQueued starts a pipeline for a queued start request, the queue has reserved its slot.
+func (a *ApiServer) launchQueued(payload json.RawMessage) (string, error) {
+	var req StartRecordingRequest
+
+	if err := json.Unmarshal(payload, &req); err != nil {
+		store.GetStore(&a.ctx).Release()
+		return "", fmt.Errorf("invalid queued payload: %w", err)
+	}
+
+	p, err := a.launchPipeline(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	return p.ID, nil
+}
+
+// launchScheduled starts a pipeline for a scheduled run, stopping it at stopAt.
+func (a *ApiServer) launchScheduled(payload json.RawMessage, stopAt time.Time) (string, error) {
+	var req StartRecordingRequest
+
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return "", fmt.Errorf("invalid scheduled payload: %w", err)
+	}
+
+	if !a.reserveSlot() {
+		return "", fmt.Errorf("node is at capacity")
+	}
+
+	req.StopAt = &stopAt
+
+	p, err := a.launchPipeline(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	return p.ID, nil
+}
+
+// reserveSlot takes a pipeline slot for a start, false when the node is running or starting its maximum number of pipelines.
+func (a *ApiServer) reserveSlot() bool {
+	return store.GetStore(&a.ctx).Reserve(env.GetMaxPipelines())
+}
+
+func (a *ApiServer) getQueueEntry(c fiber.Ctx) error {
+	q := queue.GetQueue(&a.ctx)
+
+	if q == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Start queue is not available")
+	}
+
+	entry, ok := q.Get(c.Params("id"))
+
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Queue entry not found")
+	}
+
+	return c.JSON(newQueueEntryResponse(entry))
+}
+
+func (a *ApiServer) cancelQueueEntry(c fiber.Ctx) error {
+	q := queue.GetQueue(&a.ctx)
+
+	if q == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Start queue is not available")
+	}
+
+	if _, ok := q.Get(c.Params("id")); !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Queue entry not found")
+	}
+
+	entry, err := q.Cancel(c.Params("id"))
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	return c.JSON(newQueueEntryResponse(entry))
+}
+
 func (a *ApiServer) stopRecording(c fiber.Ctx) error {
-	log.Println("Stoppping Stream Called")
 	var req StopRecordingRequest
 
 	if err := json.Unmarshal(c.Body(), &req); err != nil {
@@ -108,19 +446,339 @@ func (a *ApiServer) stopRecording(c fiber.Ctx) error {
 	resp, err := p.Stop()
 
 	if resp == nil || err != nil {
-		log.Println("Error Occured Stopping Pipeline", err)
+		a.log.Error("Error Occured Stopping Pipeline", logger.PipelineKey, p.ID, "error", err)
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to stop recording pipeline")
 	}
 
-	store.GetStore(&a.ctx).RemovePipeline(p.ID)
-
 	return c.JSON(StopRecordingResponse{
 		Status:       "Recording stopped",
 		Id:           p.ID,
 		RecordingUrl: *resp.Recording_Url,
+		StopReason:   p.Status().StopReason,
+	})
+}
+
+func (a *ApiServer) listRecordings(c fiber.Ctx) error {
+	pipelines := store.GetStore(&a.ctx).ListPipelines()
+
+	recordings := make([]pipeline.Status, 0, len(pipelines))
+
+	for _, p := range pipelines {
+		recordings = append(recordings, p.Status())
+	}
+
+	return c.JSON(ListRecordingsResponse{
+		Recordings: recordings,
+	})
+}
+
+// getRecording returns the status of a running or stopped recording.
+func (a *ApiServer) getRecording(c fiber.Ctx) error {
+	appStore := store.GetStore(&a.ctx)
+
+	if p, ok := appStore.GetPipeline(c.Params("id")); ok {
+		return c.JSON(p.Status())
+	}
+
+	if status, ok := appStore.GetFinished(c.Params("id")); ok {
+		return c.JSON(status)
+	}
+
+	return fiber.NewError(fiber.StatusNotFound, "Recording not found")
+}
+
+// getRecordingLogs returns the most recent log lines of a recording, the last ?tail= lines when set.
+func (a *ApiServer) getRecordingLogs(c fiber.Ctx) error {
+	ring, ok := logger.GetBuffers(&a.ctx).Get(c.Params("id"))
+
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Recording logs not found")
+	}
+
+	tail := 0
+
+	if raw := c.Query("tail"); raw != "" {
+		n, err := strconv.Atoi(raw)
+
+		if err != nil || n < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "tail must be a non-negative number")
+		}
+
+		tail = n
+	}
+
+	return c.JSON(RecordingLogsResponse{
+		Id:    c.Params("id"),
+		Lines: ring.Entries(tail),
+	})
+}
+
+// getRecordingPostprocess returns the status of the post-processing jobs of a recording, none while it's running.
+func (a *ApiServer) getRecordingPostprocess(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	if jobs, ok := postprocess.GetProcessor(&a.ctx).Jobs(id); ok {
+		return c.JSON(PostprocessResponse{Id: id, Jobs: jobs})
+	}
+
+	appStore := store.GetStore(&a.ctx)
+
+	if _, ok := appStore.GetPipeline(id); ok {
+		return c.JSON(PostprocessResponse{Id: id, Jobs: []postprocess.JobStatus{}})
+	}
+
+	if _, ok := appStore.GetFinished(id); ok {
+		return c.JSON(PostprocessResponse{Id: id, Jobs: []postprocess.JobStatus{}})
+	}
+
+	return fiber.NewError(fiber.StatusNotFound, "Recording not found")
+}
+
+// runRecordingActions runs an action script against the page of a live recording.
+// A failed step doesn't fail the request, the results say which step failed and why.
+func (a *ApiServer) runRecordingActions(c fiber.Ctx) error {
+	var req RunActionsRequest
+
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request payload")
+	}
+
+	if len(req.Actions) == 0 && !req.Screenshot {
+		return fiber.NewError(fiber.StatusBadRequest, "actions can't be empty unless a screenshot is asked for")
+	}
+
+	actions, err := toActions(req.Actions)
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	p, ok := store.GetStore(&a.ctx).GetPipeline(c.Params("id"))
+
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Pipeline not found")
+	}
+
+	results, err := p.RunActions(actions)
+
+	if errors.Is(err, pipeline.ErrNotRunning) {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	resp := RunActionsResponse{
+		Id:      p.ID,
+		Results: results,
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	if req.Screenshot {
+		if resp.Screenshot, err = p.Screenshot(); err != nil {
+			a.log.Warn("Failed to capture screenshot", logger.PipelineKey, p.ID, "error", err)
+		}
+	}
+
+	return c.JSON(resp)
+}
+
+// getRecordingScreenshot returns what a live recording is rendering, ?format=, ?quality=, ?scale= and ?source= shape it.
+// Screenshots are cached for SCREENSHOT_CACHE_TTL, so a dashboard polling every pipeline doesn't hammer Chrome.
+func (a *ApiServer) getRecordingScreenshot(c fiber.Ctx) error {
+	opts := display.ScreenshotOptions{
+		Source: display.ScreenshotSource(c.Query("source")),
+		Format: display.ScreenshotFormat(c.Query("format")),
+	}
+
+	if raw := c.Query("quality"); raw != "" {
+		n, err := strconv.Atoi(raw)
+
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "quality must be a number")
+		}
+
+		opts.Quality = n
+	}
+
+	if raw := c.Query("scale"); raw != "" {
+		f, err := strconv.ParseFloat(raw, 64)
+
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "scale must be a number")
+		}
+
+		opts.Scale = f
+	}
+
+	if err := opts.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	p, ok := store.GetStore(&a.ctx).GetPipeline(c.Params("id"))
+
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Pipeline not found")
+	}
+
+	ttl := env.GetScreenshotCacheTTL()
+	thumbnail, err := p.Thumbnail(opts, ttl)
+
+	if errors.Is(err, pipeline.ErrNotRunning) {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	if err != nil {
+		a.log.Warn("Failed to capture screenshot", logger.PipelineKey, p.ID, "error", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to capture screenshot")
+	}
+
+	c.Set(fiber.HeaderContentType, "image/"+string(opts.Format))
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", int(ttl.Seconds())))
+	c.Set(fiber.HeaderLastModified, thumbnail.CapturedAt.Format(http.TimeFormat))
+
+	return c.Send(thumbnail.Image)
+}
+
+func (a *ApiServer) createSchedule(c fiber.Ctx) error {
+	sc := scheduler.GetScheduler(&a.ctx)
+
+	if sc == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Scheduler is not available")
+	}
+
+	var req ScheduleRequest
+
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request payload")
+	}
+
+	schedule, err := req.toSchedule()
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	schedule, err = sc.Create(schedule)
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newScheduleResponse(schedule))
+}
+
+func (a *ApiServer) listSchedules(c fiber.Ctx) error {
+	sc := scheduler.GetScheduler(&a.ctx)
+
+	if sc == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Scheduler is not available")
+	}
+
+	schedules := sc.List()
+	resp := ListSchedulesResponse{
+		Schedules: make([]ScheduleResponse, 0, len(schedules)),
+	}
+
+	for _, schedule := range schedules {
+		resp.Schedules = append(resp.Schedules, newScheduleResponse(schedule))
+	}
+
+	return c.JSON(resp)
+}
+
+// getSchedule returns a schedule along with the pipelines it has launched.
+func (a *ApiServer) getSchedule(c fiber.Ctx) error {
+	sc := scheduler.GetScheduler(&a.ctx)
+
+	if sc == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Scheduler is not available")
+	}
+
+	schedule, ok := sc.Get(c.Params("id"))
+
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Schedule not found")
+	}
+
+	return c.JSON(newScheduleResponse(schedule))
+}
+
+func (a *ApiServer) updateSchedule(c fiber.Ctx) error {
+	sc := scheduler.GetScheduler(&a.ctx)
+
+	if sc == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Scheduler is not available")
+	}
+
+	if _, ok := sc.Get(c.Params("id")); !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Schedule not found")
+	}
+
+	var req ScheduleRequest
+
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request payload")
+	}
+
+	update, err := req.toSchedule()
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	schedule, err := sc.Update(c.Params("id"), update)
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(newScheduleResponse(schedule))
+}
+
+func (a *ApiServer) deleteSchedule(c fiber.Ctx) error {
+	sc := scheduler.GetScheduler(&a.ctx)
+
+	if sc == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Scheduler is not available")
+	}
+
+	if err := sc.Delete(c.Params("id")); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Schedule not found")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// listDeliveries lists webhook deliveries, filtered by the pipeline_id query parameter when set.
+func (a *ApiServer) listDeliveries(c fiber.Ctx) error {
+	n := webhook.GetNotifier(&a.ctx)
+
+	if n == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Webhooks are not available")
+	}
+
+	return c.JSON(ListDeliveriesResponse{
+		Deliveries: n.List(c.Query("pipeline_id")),
 	})
 }
 
+func (a *ApiServer) getDelivery(c fiber.Ctx) error {
+	n := webhook.GetNotifier(&a.ctx)
+
+	if n == nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Webhooks are not available")
+	}
+
+	delivery, ok := n.Get(c.Params("id"))
+
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Delivery not found")
+	}
+
+	return c.JSON(delivery)
+}
+
 // errorHandler handles all internal server errors.
 func errorHandler(c fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
@@ -130,7 +788,7 @@ func errorHandler(c fiber.Ctx, err error) error {
 		msg = e.Message
 	}
 	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
-	log.Printf("Error %d: %s\n", code, msg)
+	logger.Component("api").Info("Request failed", "method", c.Method(), "path", c.Path(), "status", code, "message", msg)
 	return c.Status(code).SendString(msg)
 }
 
@@ -150,21 +808,21 @@ func (a *ApiServer) Start() <-chan struct{} {
 			DisableStartupMessage: true,
 			GracefulContext:       a.ctx,
 			OnShutdownError: func(err error) {
-				log.Printf("error shutting down the server: %v\n", err)
+				a.log.Error("Error shutting down the server", "error", err)
 				close(a.done)
 			},
 			OnShutdownSuccess: func() {
-				log.Println("server shutdown successfully")
+				a.log.Info("Server shutdown successfully")
 				close(a.done)
 			},
 			ListenerAddrFunc: func(net.Addr) {
-				log.Printf("apiServer listening on :%d \n", a.opts.Port)
+				a.log.Info("API server listening", "port", a.opts.Port)
 				close(startedChan)
 			},
 		})
 
 		if err != nil {
-			log.Printf("Error starting the server: %v\n", err)
+			a.log.Error("Error starting the server", "error", err)
 			close(startedChan)
 		}
 	}()
@@ -174,7 +832,7 @@ func (a *ApiServer) Start() <-chan struct{} {
 
 // Close gracefully shuts down the server.
 func (a *ApiServer) Close() error {
-	log.Println("closing the API server")
+	a.log.Info("Closing the API server")
 
 	return a.app.Shutdown()
 }
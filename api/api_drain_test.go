@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/api"
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/drain"
+	"github.com/OmGuptaIND/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainRejectsStartsAndFailsReadiness(t *testing.T) {
+	appStore := store.NewStore()
+
+	drainer := drain.NewDrainer(&drain.DrainerOptions{
+		Timeout:      time.Minute,
+		Store:        appStore,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, config.StoreKey, appStore)
+	ctx = context.WithValue(ctx, config.DrainerKey, drainer)
+
+	apiServer := api.NewApiServer(ctx, api.ApiServerOptions{Port: 3105})
+	<-apiServer.Start()
+
+	t.Cleanup(func() {
+		cancel()
+		<-apiServer.Done()
+	})
+
+	resp, err := http.Get("http://localhost:3105/readyz")
+
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, err = http.Post("http://localhost:3105/admin/drain", "application/json", nil)
+
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://localhost:3105/readyz")
+
+	if assert.NoError(t, err) {
+		var ready api.ReadyResponse
+
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&ready))
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "draining", ready.Status)
+	}
+
+	resp, err = http.Post("http://localhost:3105/start-recording", "application/json",
+		strings.NewReader(`{"record_url": "https://example.com", "stream_url": ""}`))
+
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	// Nothing was running, so the drain is over right away, and nothing was reserved by the rejected start.
+	select {
+	case <-drainer.Done():
+	case <-time.After(time.Second):
+		t.Fatal("drain did not end")
+	}
+
+	assert.Zero(t, appStore.Count())
+}
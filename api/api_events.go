@@ -0,0 +1,252 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/OmGuptaIND/events"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/gofiber/fiber/v3"
+)
+
+// websocketGUID is the fixed GUID of the Sec-WebSocket-Accept handshake, RFC 6455 section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// eventsHeartbeat keeps idle event streams from being closed by proxies.
+const eventsHeartbeat = 15 * time.Second
+
+// Limits of the frames a client may send, it only ever has to answer pings and close the stream.
+const (
+	maxControlPayload = 125
+	maxDataPayload    = 4 * 1024
+)
+
+var (
+	errFrameTooLarge = errors.New("websocket frame too large")
+	errUnmaskedFrame = errors.New("websocket client frame is not masked")
+)
+
+// subscribeEvents subscribes to the event bus, filtered by the pipeline_id query parameter when set.
+func (a *ApiServer) subscribeEvents(c fiber.Ctx) (*events.Subscription, error) {
+	bus := events.GetBus(&a.ctx)
+
+	if bus == nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Events are not available")
+	}
+
+	pipelineID := c.Query("pipeline_id")
+
+	return bus.Subscribe(256, func(e events.Event) bool {
+		return pipelineID == "" || e.PipelineID == pipelineID
+	}), nil
+}
+
+// streamEvents streams pipeline events as Server-Sent Events.
+func (a *ApiServer) streamEvents(c fiber.Ctx) error {
+	sub, err := a.subscribeEvents(c)
+
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	done := a.ctx.Done()
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		// Flush the headers right away so clients know they are connected.
+		fmt.Fprint(w, ": connected\n\n")
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+
+				data, err := json.Marshal(e)
+
+				if err != nil {
+					a.log.Error("Failed to encode event", "event", e.Type, "error", err)
+					continue
+				}
+
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+			}
+
+			// A failed flush means the client went away.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// streamEventsWebSocket streams pipeline events over a WebSocket, one JSON text message per event.
+func (a *ApiServer) streamEventsWebSocket(c fiber.Ctx) error {
+	if !strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") {
+		return fiber.NewError(fiber.StatusUpgradeRequired, "Expected a WebSocket upgrade")
+	}
+
+	if c.Get("Sec-WebSocket-Version") != "13" {
+		c.Set("Sec-WebSocket-Version", "13")
+		return fiber.NewError(fiber.StatusUpgradeRequired, "Unsupported WebSocket version, expected 13")
+	}
+
+	key := c.Get("Sec-WebSocket-Key")
+
+	if key == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Missing Sec-WebSocket-Key")
+	}
+
+	sub, err := a.subscribeEvents(c)
+
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderUpgrade, "websocket")
+	c.Set(fiber.HeaderConnection, "Upgrade")
+	c.Set("Sec-WebSocket-Accept", websocketAccept(key))
+	c.Status(fiber.StatusSwitchingProtocols)
+
+	done := a.ctx.Done()
+
+	c.Context().Hijack(func(conn net.Conn) {
+		defer conn.Close()
+		defer sub.Close()
+
+		// Read in the background to notice when the client closes. Pings are handed
+		// to the loop below so that it stays the only writer of the connection.
+		closed := make(chan struct{})
+		pings := make(chan []byte, 1)
+
+		var readErr error
+
+		go func() {
+			defer close(closed)
+			readErr = readClientFrames(conn, pings)
+		}()
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-done:
+				wsutil.WriteServerMessage(conn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusGoingAway, "server shutting down"))
+				return
+			case <-closed:
+				if errors.Is(readErr, errFrameTooLarge) {
+					wsutil.WriteServerMessage(conn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusMessageTooBig, "frame too large"))
+				}
+
+				return
+			case payload := <-pings:
+				if err := wsutil.WriteServerMessage(conn, ws.OpPong, payload); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if err := wsutil.WriteServerMessage(conn, ws.OpPing, nil); err != nil {
+					return
+				}
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+
+				data, err := json.Marshal(e)
+
+				if err != nil {
+					a.log.Error("Failed to encode event", "event", e.Type, "error", err)
+					continue
+				}
+
+				if err := wsutil.WriteServerText(conn, data); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// readClientFrames reads the frames of a client until it closes the stream, handing its pings over.
+// The length of a frame is checked against the limits before its payload is read.
+func readClientFrames(r io.Reader, pings chan<- []byte) error {
+	for {
+		header, err := ws.ReadHeader(r)
+
+		if err != nil {
+			return err
+		}
+
+		limit := int64(maxDataPayload)
+
+		if header.OpCode.IsControl() {
+			limit = maxControlPayload
+		}
+
+		if header.Length > limit {
+			return fmt.Errorf("%w: %d bytes", errFrameTooLarge, header.Length)
+		}
+
+		if !header.Masked {
+			return errUnmaskedFrame
+		}
+
+		payload := make([]byte, header.Length)
+
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+
+		ws.Cipher(payload, header.Mask, 0)
+
+		switch header.OpCode {
+		case ws.OpClose:
+			return nil
+		case ws.OpPing:
+			select {
+			case pings <- payload:
+			default:
+			}
+		}
+	}
+}
+
+// websocketAccept computes the Sec-WebSocket-Accept value for a client key.
+func websocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,163 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/api"
+	"github.com/OmGuptaIND/events"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// startEventsServer starts an API server with an event bus, shut down with the test.
+func startEventsServer(t *testing.T, port int) *events.Bus {
+	bus := events.NewBus()
+	ctx, cancel := context.WithCancel(events.WithBus(context.Background(), bus))
+
+	apiServer := api.NewApiServer(ctx, api.ApiServerOptions{Port: port})
+	<-apiServer.Start()
+
+	t.Cleanup(func() {
+		cancel()
+		<-apiServer.Done()
+	})
+
+	return bus
+}
+
+// dialWebSocket runs the opening handshake with the RFC 6455 sample key, returning the connection and the response.
+func dialWebSocket(t *testing.T, port int, query string, version string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	fmt.Fprintf(conn, "GET /events/ws%s HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: %s\r\n\r\n", query, version)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return conn, br, resp
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	startEventsServer(t, 3101)
+
+	_, _, resp := dialWebSocket(t, 3101, "", "13")
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	// The sample handshake of RFC 6455 section 1.3.
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	_, _, resp = dialWebSocket(t, 3101, "", "8")
+
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get("Sec-WebSocket-Version"))
+}
+
+func TestWebSocketPipelineFilter(t *testing.T) {
+	bus := startEventsServer(t, 3102)
+
+	conn, br, resp := dialWebSocket(t, 3102, "?pipeline_id=pipeline_b", "13")
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	bus.Emit("pipeline_a", events.PipelineStarted, nil)
+	bus.Emit("pipeline_b", events.PipelineStarted, nil)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	data, op, err := wsutil.ReadServerData(struct {
+		io.Reader
+		io.Writer
+	}{br, conn})
+
+	var e events.Event
+
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &e))
+	assert.Equal(t, ws.OpText, op)
+	assert.Equal(t, "pipeline_b", e.PipelineID)
+	assert.Equal(t, events.PipelineStarted, e.Type)
+}
+
+func TestWebSocketOversizedFrame(t *testing.T) {
+	startEventsServer(t, 3103)
+
+	conn, br, resp := dialWebSocket(t, 3103, "", "13")
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	// A masked binary frame declaring 1TB, the server must give up on it without reading nor allocating it.
+	header := []byte{0x82, 0x80 | 127}
+	header = binary.BigEndian.AppendUint64(header, 1<<40)
+	header = append(header, 1, 2, 3, 4)
+
+	_, err := conn.Write(header)
+	assert.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	frame, err := ws.ReadFrame(br)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ws.OpClose, frame.Header.OpCode)
+
+	code, _ := ws.ParseCloseFrameData(frame.Payload)
+	assert.Equal(t, ws.StatusMessageTooBig, code)
+}
+
+func TestServerSentEventsPipelineFilter(t *testing.T) {
+	bus := startEventsServer(t, 3104)
+
+	resp, err := http.Get("http://localhost:3104/events?pipeline_id=pipeline_b")
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+
+	// The stream is subscribed once the connected comment is in.
+	assert.True(t, lines.Scan())
+	assert.Equal(t, ": connected", lines.Text())
+
+	bus.Emit("pipeline_a", events.PipelineStarted, nil)
+	bus.Emit("pipeline_b", events.PipelineStopped, nil)
+
+	var data string
+
+	for lines.Scan() {
+		if after, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			data = after
+			break
+		}
+	}
+
+	var e events.Event
+
+	assert.NoError(t, json.Unmarshal([]byte(data), &e))
+	assert.Equal(t, "pipeline_b", e.PipelineID)
+	assert.Equal(t, events.PipelineStopped, e.Type)
+}
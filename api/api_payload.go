@@ -1,6 +1,22 @@
 package api
 
-import "github.com/OmGuptaIND/recorder"
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/health"
+	"github.com/OmGuptaIND/hls"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/pipeline"
+	"github.com/OmGuptaIND/postprocess"
+	"github.com/OmGuptaIND/quality"
+	"github.com/OmGuptaIND/queue"
+	"github.com/OmGuptaIND/scheduler"
+	"github.com/OmGuptaIND/webhook"
+)
 
 type ChunkRequest struct {
 	Duration string `json:"duration"`
@@ -9,6 +25,61 @@ type ChunkRequest struct {
 type StartRecordingRequest struct {
 	RecordUrl string `json:"record_url"`
 	StreamUrl string `json:"stream_url"`
+
+	// MaxDuration is a duration string like "90m", the recording stops once it runs this long.
+	MaxDuration string `json:"max_duration,omitempty"`
+	// StopAt is an RFC 3339 time at which the recording stops.
+	StopAt *time.Time `json:"stop_at,omitempty"`
+
+	// WebhookUrl receives this pipeline's events, on top of the node-wide WEBHOOK_URL.
+	WebhookUrl string `json:"webhook_url,omitempty"`
+	// WebhookSecret signs this pipeline's webhook payloads, defaults to WEBHOOK_SECRET.
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+
+	// Quality overrides the node-wide silence, black and freeze checks for this recording.
+	Quality *QualityRequest `json:"quality,omitempty"`
+
+	// Ready holds the recording back until the page is ready.
+	Ready *ReadyRequest `json:"ready,omitempty"`
+
+	// End lets the page stop its own recording.
+	End *EndRequest `json:"end,omitempty"`
+
+	// Actions is a script run against the page once it has loaded, before it's recorded.
+	Actions []ActionRequest `json:"actions,omitempty"`
+
+	// Cookies, LocalStorage, SessionStorage, Headers and BasicAuth log the browser in before the page is loaded.
+	// Their values are secrets, they are never logged nor returned.
+	Cookies        []CookieRequest   `json:"cookies,omitempty"`
+	LocalStorage   map[string]string `json:"local_storage,omitempty"`
+	SessionStorage map[string]string `json:"session_storage,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	BasicAuth      *BasicAuthRequest `json:"basic_auth,omitempty"`
+
+	// Emulation renders the page as another device, locale or timezone would.
+	Emulation *EmulationRequest `json:"emulation,omitempty"`
+
+	// Proxy routes the traffic of the browser through an HTTP or SOCKS proxy.
+	Proxy *ProxyRequest `json:"proxy,omitempty"`
+	// Network blocks, or only allows, requests of the page by URL pattern.
+	Network *NetworkRequest `json:"network,omitempty"`
+
+	// Output records an HLS playlist instead of an MP4, defaults to OUTPUT_FORMAT.
+	Output *OutputRequest `json:"output,omitempty"`
+
+	// Queue places the request on the start queue instead of starting it right away.
+	Queue    bool `json:"queue,omitempty"`
+	Priority int  `json:"priority,omitempty"`
+}
+
+// QualityRequest holds duration strings like "30s", a recording silent, black or frozen for longer is reported. "0s" turns a check off.
+type QualityRequest struct {
+	SilenceAfter string `json:"silence_after,omitempty"`
+	BlackAfter   string `json:"black_after,omitempty"`
+	FreezeAfter  string `json:"freeze_after,omitempty"`
+
+	// Fail stops the recording as soon as a check trips.
+	Fail *bool `json:"fail,omitempty"`
 }
 
 type StartRecordingResponse struct {
@@ -21,11 +92,662 @@ type StopRecordingRequest struct {
 }
 
 type StopRecordingResponse struct {
-	Id           string `json:"id"`
-	Status       string `json:"status"`
-	RecordingUrl string `json:"recording_url,omitempty"`
+	Id           string              `json:"id"`
+	Status       string              `json:"status"`
+	RecordingUrl string              `json:"recording_url,omitempty"`
+	StopReason   pipeline.StopReason `json:"stop_reason,omitempty"`
 }
 
 type ListRecordingsResponse struct {
-	Recordings map[string]recorder.Recorder `json:"recordings"`
+	Recordings []pipeline.Status `json:"recordings"`
+}
+
+// Validate checks the fields that unmarshalling alone can't.
+func (r StartRecordingRequest) Validate() error {
+	if r.MaxDuration != "" {
+		d, err := time.ParseDuration(r.MaxDuration)
+
+		if err != nil {
+			return fmt.Errorf("invalid max_duration: %w", err)
+		}
+
+		if d <= 0 {
+			return fmt.Errorf("max_duration must be positive")
+		}
+	}
+
+	if r.StopAt != nil && !r.StopAt.After(time.Now()) {
+		return fmt.Errorf("stop_at must be in the future")
+	}
+
+	if r.Quality != nil {
+		for name, value := range map[string]string{
+			"silence_after": r.Quality.SilenceAfter,
+			"black_after":   r.Quality.BlackAfter,
+			"freeze_after":  r.Quality.FreezeAfter,
+		} {
+			if value == "" {
+				continue
+			}
+
+			if d, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid quality.%s: %w", name, err)
+			} else if d < 0 {
+				return fmt.Errorf("quality.%s can't be negative", name)
+			}
+		}
+	}
+
+	if r.Ready != nil {
+		if err := r.Ready.validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.End != nil {
+		if c := r.End.condition(); !c.Enabled() {
+			return fmt.Errorf("end needs an event, a console pattern, an expression, a media_selector or a url pattern")
+		} else if err := c.Validate(); err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+	}
+
+	if _, err := toActions(r.Actions); err != nil {
+		return fmt.Errorf("invalid actions: %w", err)
+	}
+
+	if err := r.session().Validate(); err != nil {
+		return fmt.Errorf("invalid session: %w", err)
+	}
+
+	if err := r.Emulation.emulation().Validate(); err != nil {
+		return fmt.Errorf("invalid emulation: %w", err)
+	}
+
+	if err := r.Proxy.proxy().Validate(); err != nil {
+		return fmt.Errorf("invalid proxy: %w", err)
+	}
+
+	if err := r.Network.rules().Validate(); err != nil {
+		return fmt.Errorf("invalid network: %w", err)
+	}
+
+	// The node-wide defaults are checked at startup, any valid ones do to check the request.
+	if _, err := r.Output.hls("hls", hls.DefaultOptions); err != nil {
+		return err
+	}
+
+	if r.WebhookUrl != "" {
+		if u, err := url.Parse(r.WebhookUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook_url must be an http or https URL")
+		}
+	}
+
+	return nil
+}
+
+// maxDuration returns the requested max duration, capped at the node-wide limit.
+func (r StartRecordingRequest) maxDuration(nodeCap time.Duration) time.Duration {
+	d, _ := time.ParseDuration(r.MaxDuration)
+
+	if nodeCap > 0 && (d <= 0 || d > nodeCap) {
+		return nodeCap
+	}
+
+	return d
+}
+
+// qualityChecks returns the quality thresholds of the request, the node-wide defaults fill in what it leaves out.
+func (r StartRecordingRequest) qualityChecks(defaults quality.Thresholds) quality.Thresholds {
+	t := defaults
+
+	if r.Quality == nil {
+		return t
+	}
+
+	if d, err := time.ParseDuration(r.Quality.SilenceAfter); err == nil {
+		t.SilenceAfter = d
+	}
+
+	if d, err := time.ParseDuration(r.Quality.BlackAfter); err == nil {
+		t.BlackAfter = d
+	}
+
+	if d, err := time.ParseDuration(r.Quality.FreezeAfter); err == nil {
+		t.FreezeAfter = d
+	}
+
+	if r.Quality.Fail != nil {
+		t.Fail = *r.Quality.Fail
+	}
+
+	return t
+}
+
+// ReadyRequest is what the page has to meet before it's recorded, every condition set must hold.
+// Durations are strings like "2s".
+type ReadyRequest struct {
+	// Selector is a CSS selector that has to be visible.
+	Selector string `json:"selector,omitempty"`
+	// Expression is a JavaScript expression that has to become truthy.
+	Expression string `json:"expression,omitempty"`
+	// NetworkIdle is how long the page must go without a request in flight.
+	NetworkIdle string `json:"network_idle,omitempty"`
+	Delay       string `json:"delay,omitempty"`
+
+	// Timeout bounds the wait, it defaults to 30s.
+	Timeout string `json:"timeout,omitempty"`
+	// ProceedOnTimeout records anyway when the page isn't ready in time, instead of failing the recording.
+	ProceedOnTimeout bool `json:"proceed_on_timeout,omitempty"`
+}
+
+// validate checks the durations of the request and that it waits for something.
+func (r *ReadyRequest) validate() error {
+	for name, value := range map[string]string{
+		"network_idle": r.NetworkIdle,
+		"delay":        r.Delay,
+		"timeout":      r.Timeout,
+	} {
+		if value == "" {
+			continue
+		}
+
+		if d, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid ready.%s: %w", name, err)
+		} else if d < 0 {
+			return fmt.Errorf("ready.%s can't be negative", name)
+		}
+	}
+
+	if !r.condition().Enabled() {
+		return fmt.Errorf("ready needs a selector, an expression, a network_idle or a delay")
+	}
+
+	return nil
+}
+
+// condition converts the request, a nil request has nothing to wait for.
+func (r *ReadyRequest) condition() display.ReadyCondition {
+	if r == nil {
+		return display.ReadyCondition{}
+	}
+
+	c := display.ReadyCondition{
+		Selector:   r.Selector,
+		Expression: r.Expression,
+		Proceed:    r.ProceedOnTimeout,
+	}
+
+	c.NetworkIdle, _ = time.ParseDuration(r.NetworkIdle)
+	c.Delay, _ = time.ParseDuration(r.Delay)
+	c.Timeout, _ = time.ParseDuration(r.Timeout)
+
+	return c
+}
+
+// EndRequest is how the page ends its own recording, the first condition met stops it.
+type EndRequest struct {
+	// Event is the name of an event dispatched on window.
+	Event string `json:"event,omitempty"`
+	// Console is a regular expression matched against console messages.
+	Console string `json:"console,omitempty"`
+	// Expression is a JavaScript expression that ends the recording once truthy.
+	Expression string `json:"expression,omitempty"`
+	// MediaSelector is a CSS selector of the audio or video elements whose ended event ends the recording.
+	MediaSelector string `json:"media_selector,omitempty"`
+	// Url is a regular expression matched against the URL the page navigates to.
+	Url string `json:"url,omitempty"`
+}
+
+// condition converts the request, a nil request never ends the recording.
+func (r *EndRequest) condition() display.EndCondition {
+	if r == nil {
+		return display.EndCondition{}
+	}
+
+	return display.EndCondition{
+		Event:         r.Event,
+		Console:       r.Console,
+		Expression:    r.Expression,
+		MediaSelector: r.MediaSelector,
+		URL:           r.Url,
+	}
+}
+
+// ActionRequest is a single step of an action script, durations are strings like "2s".
+type ActionRequest struct {
+	// Type is one of navigate, click, type, press, wait_for, evaluate, sleep or scroll.
+	Type       string `json:"type"`
+	Selector   string `json:"selector,omitempty"`
+	Url        string `json:"url,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Key        string `json:"key,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	X          int    `json:"x,omitempty"`
+	Y          int    `json:"y,omitempty"`
+
+	// Timeout bounds the step, it defaults to 10s and can't exceed 1m.
+	Timeout string `json:"timeout,omitempty"`
+	// Optional lets the script carry on when the step fails.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// toActions converts and validates an action script.
+func toActions(reqs []ActionRequest) ([]display.Action, error) {
+	actions := make([]display.Action, 0, len(reqs))
+
+	for i, r := range reqs {
+		a := display.Action{
+			Type:       display.ActionType(r.Type),
+			Selector:   r.Selector,
+			URL:        r.Url,
+			Text:       r.Text,
+			Key:        r.Key,
+			Expression: r.Expression,
+			X:          r.X,
+			Y:          r.Y,
+			Optional:   r.Optional,
+		}
+
+		var err error
+
+		if r.Duration != "" {
+			if a.Duration, err = time.ParseDuration(r.Duration); err != nil {
+				return nil, fmt.Errorf("action %d: invalid duration: %w", i, err)
+			}
+		}
+
+		if r.Timeout != "" {
+			if a.Timeout, err = time.ParseDuration(r.Timeout); err != nil {
+				return nil, fmt.Errorf("action %d: invalid timeout: %w", i, err)
+			}
+		}
+
+		actions = append(actions, a)
+	}
+
+	if err := display.ValidateActions(actions); err != nil {
+		return nil, err
+	}
+
+	return actions, nil
+}
+
+// CookieRequest is a cookie set before the page is loaded, the domain defaults to the host of the record URL.
+type CookieRequest struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	HttpOnly bool   `json:"http_only,omitempty"`
+	// SameSite is Strict, Lax or None.
+	SameSite string     `json:"same_site,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+}
+
+type BasicAuthRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// session converts the cookies, storage, headers and credentials of the request.
+func (r StartRecordingRequest) session() display.Session {
+	s := display.Session{
+		LocalStorage:   r.LocalStorage,
+		SessionStorage: r.SessionStorage,
+		Headers:        r.Headers,
+	}
+
+	for _, c := range r.Cookies {
+		cookie := display.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+			SameSite: c.SameSite,
+		}
+
+		if c.Expires != nil {
+			cookie.Expires = *c.Expires
+		}
+
+		s.Cookies = append(s.Cookies, cookie)
+	}
+
+	if r.BasicAuth != nil {
+		s.BasicAuth = &display.BasicAuth{
+			Username: r.BasicAuth.Username,
+			Password: r.BasicAuth.Password,
+		}
+	}
+
+	return s
+}
+
+// redacted returns a copy of the request safe to hand back, with the secrets it carries replaced.
+func (r StartRecordingRequest) redacted() StartRecordingRequest {
+	// The webhook secret is write only.
+	r.WebhookSecret = ""
+
+	if len(r.Cookies) > 0 {
+		cookies := make([]CookieRequest, len(r.Cookies))
+
+		for i, c := range r.Cookies {
+			c.Value = redactedValue
+			cookies[i] = c
+		}
+
+		r.Cookies = cookies
+	}
+
+	r.LocalStorage = redactValues(r.LocalStorage)
+	r.SessionStorage = redactValues(r.SessionStorage)
+	r.Headers = redactValues(r.Headers)
+
+	if r.BasicAuth != nil {
+		r.BasicAuth = &BasicAuthRequest{
+			Username: r.BasicAuth.Username,
+			Password: redactedValue,
+		}
+	}
+
+	if r.Proxy != nil && r.Proxy.Password != "" {
+		proxy := *r.Proxy
+		proxy.Password = redactedValue
+		r.Proxy = &proxy
+	}
+
+	return r
+}
+
+// redactedValue replaces the secret values handed back by the API.
+const redactedValue = "[redacted]"
+
+// redactValues returns a copy of m with every value replaced, keeping the keys.
+func redactValues(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return m
+	}
+
+	redacted := make(map[string]string, len(m))
+
+	for k := range m {
+		redacted[k] = redactedValue
+	}
+
+	return redacted
+}
+
+// EmulationRequest overrides how the page renders, fields left out keep the browser defaults.
+type EmulationRequest struct {
+	// Timezone is an IANA timezone like "Europe/Berlin".
+	Timezone string `json:"timezone,omitempty"`
+	// Locale is a BCP 47 language tag like "de-DE".
+	Locale string `json:"locale,omitempty"`
+	// ColorScheme is light, dark or no-preference.
+	ColorScheme string `json:"color_scheme,omitempty"`
+
+	// Width and Height override the viewport, in CSS pixels.
+	Width             int     `json:"width,omitempty"`
+	Height            int     `json:"height,omitempty"`
+	DeviceScaleFactor float64 `json:"device_scale_factor,omitempty"`
+	Mobile            bool    `json:"mobile,omitempty"`
+
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// emulation converts the request, a nil request emulates nothing.
+func (r *EmulationRequest) emulation() display.Emulation {
+	if r == nil {
+		return display.Emulation{}
+	}
+
+	return display.Emulation{
+		Timezone:          r.Timezone,
+		Locale:            r.Locale,
+		ColorScheme:       display.ColorScheme(r.ColorScheme),
+		Width:             r.Width,
+		Height:            r.Height,
+		DeviceScaleFactor: r.DeviceScaleFactor,
+		Mobile:            r.Mobile,
+		UserAgent:         r.UserAgent,
+	}
+}
+
+type ProxyRequest struct {
+	// Server is the proxy URL, e.g. "http://proxy.internal:3128" or "socks5://proxy.internal:1080".
+	Server string `json:"server"`
+	// Bypass lists the hosts reached directly.
+	Bypass []string `json:"bypass,omitempty"`
+	// Username and Password authenticate to an HTTP proxy.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// proxy converts the request, a nil request uses no proxy.
+func (r *ProxyRequest) proxy() display.Proxy {
+	if r == nil {
+		return display.Proxy{}
+	}
+
+	return display.Proxy{
+		Server:   r.Server,
+		Bypass:   r.Bypass,
+		Username: r.Username,
+		Password: r.Password,
+	}
+}
+
+// OutputRequest is what the recording is written as. SegmentDuration is a string like "6s".
+type OutputRequest struct {
+	// Format is mp4 or hls.
+	Format string `json:"format,omitempty"`
+	// SegmentType is fmp4 or ts.
+	SegmentType     string `json:"segment_type,omitempty"`
+	SegmentDuration string `json:"segment_duration,omitempty"`
+}
+
+// hls converts the request, the node-wide format and defaults fill in what it leaves out. Nil records an MP4.
+func (r *OutputRequest) hls(format string, defaults hls.Options) (*hls.Options, error) {
+	opts := defaults
+
+	if r != nil {
+		if r.Format != "" {
+			format = r.Format
+		}
+
+		if r.SegmentType != "" {
+			opts.SegmentType = hls.SegmentType(r.SegmentType)
+		}
+
+		if r.SegmentDuration != "" {
+			d, err := time.ParseDuration(r.SegmentDuration)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid output.segment_duration: %w", err)
+			}
+
+			opts.SegmentDuration = d
+		}
+	}
+
+	switch format {
+	case "mp4":
+		return nil, nil
+	case "hls":
+		if err := opts.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid output: %w", err)
+		}
+
+		return &opts, nil
+	}
+
+	return nil, fmt.Errorf("invalid output.format %q, must be mp4 or hls", format)
+}
+
+// NetworkRequest holds URL patterns, '*' matches any run of characters.
+type NetworkRequest struct {
+	Block []string `json:"block,omitempty"`
+	// Allow, when set, fails every request that doesn't match one of its patterns.
+	Allow []string `json:"allow,omitempty"`
+}
+
+// rules converts the request, a nil request lets every request through.
+func (r *NetworkRequest) rules() display.NetworkRules {
+	if r == nil {
+		return display.NetworkRules{}
+	}
+
+	return display.NetworkRules{
+		Block: r.Block,
+		Allow: r.Allow,
+	}
+}
+
+type RunActionsRequest struct {
+	Actions []ActionRequest `json:"actions"`
+	// Screenshot returns a PNG of the page once the actions have run.
+	Screenshot bool `json:"screenshot,omitempty"`
+}
+
+type RunActionsResponse struct {
+	Id      string                 `json:"id"`
+	Results []display.ActionResult `json:"results"`
+	Error   string                 `json:"error,omitempty"`
+	// Screenshot is the base64 encoded PNG of the page.
+	Screenshot []byte `json:"screenshot,omitempty"`
+}
+
+// ScheduleRequest describes a future, optionally recurring, recording.
+// The start request fields are embedded, each run is launched with them.
+type ScheduleRequest struct {
+	StartRecordingRequest
+
+	StartAt    time.Time `json:"start_at"`
+	Duration   string    `json:"duration"`
+	Recurrence string    `json:"recurrence,omitempty"`
+	Timezone   string    `json:"timezone,omitempty"`
+}
+
+type ScheduleResponse struct {
+	Id         string                `json:"id"`
+	StartAt    time.Time             `json:"start_at"`
+	Duration   string                `json:"duration"`
+	Recurrence string                `json:"recurrence,omitempty"`
+	Timezone   string                `json:"timezone,omitempty"`
+	Request    StartRecordingRequest `json:"request"`
+	NextRun    *time.Time            `json:"next_run,omitempty"`
+	Runs       []scheduler.Run       `json:"runs"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+}
+
+type ListSchedulesResponse struct {
+	Schedules []ScheduleResponse `json:"schedules"`
+}
+
+// toSchedule converts the request into a schedule, the run timing replaces stop_at, max_duration and queue.
+func (r ScheduleRequest) toSchedule() (*scheduler.Schedule, error) {
+	req := r.StartRecordingRequest
+	req.StopAt = nil
+	req.MaxDuration = ""
+	req.Queue = false
+	req.Priority = 0
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduler.Schedule{
+		StartAt:    r.StartAt,
+		Duration:   r.Duration,
+		Recurrence: r.Recurrence,
+		Timezone:   r.Timezone,
+		Payload:    payload,
+	}, nil
+}
+
+func newScheduleResponse(s *scheduler.Schedule) ScheduleResponse {
+	var req StartRecordingRequest
+
+	if err := json.Unmarshal(s.Payload, &req); err != nil {
+		logger.Component("api").Warn("Failed to decode schedule payload", "schedule_id", s.ID, "error", err)
+	}
+
+	req = req.redacted()
+
+	return ScheduleResponse{
+		Id:         s.ID,
+		StartAt:    s.StartAt,
+		Duration:   s.Duration,
+		Recurrence: s.Recurrence,
+		Timezone:   s.Timezone,
+		Request:    req,
+		NextRun:    s.NextRun,
+		Runs:       s.Runs,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+type ListDeliveriesResponse struct {
+	Deliveries []*webhook.Delivery `json:"deliveries"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+	Uptime string `json:"uptime"`
+}
+
+type ReadyResponse struct {
+	Status  string                   `json:"status"`
+	Failing []string                 `json:"failing,omitempty"`
+	Checks  map[string]health.Result `json:"checks,omitempty"`
+}
+
+type DrainResponse struct {
+	Status    string `json:"status"`
+	Pipelines int    `json:"pipelines"`
+}
+
+type QueueEntryResponse struct {
+	Id         string       `json:"id"`
+	Status     queue.Status `json:"status"`
+	Priority   int          `json:"priority"`
+	PipelineId string       `json:"pipeline_id,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+func newQueueEntryResponse(e *queue.Entry) QueueEntryResponse {
+	return QueueEntryResponse{
+		Id:         e.ID,
+		Status:     e.Status,
+		Priority:   e.Priority,
+		PipelineId: e.PipelineID,
+		Error:      e.Error,
+		CreatedAt:  e.CreatedAt,
+		UpdatedAt:  e.UpdatedAt,
+	}
+}
+
+type RecordingLogsResponse struct {
+	Id    string         `json:"id"`
+	Lines []logger.Entry `json:"lines"`
+}
+
+type PostprocessResponse struct {
+	Id   string                  `json:"id"`
+	Jobs []postprocess.JobStatus `json:"jobs"`
 }
@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactedHidesEverySecret(t *testing.T) {
+	req := api.StartRecordingRequest{
+		RecordUrl:      "https://app.example.com/meeting/1",
+		WebhookUrl:     "https://hooks.example.com",
+		WebhookSecret:  "secret-webhook",
+		Cookies:        []api.CookieRequest{{Name: "session", Value: "secret-cookie", Domain: "app.example.com"}},
+		LocalStorage:   map[string]string{"token": "secret-local"},
+		SessionStorage: map[string]string{"token": "secret-session"},
+		Headers:        map[string]string{"Authorization": "secret-header"},
+		BasicAuth:      &api.BasicAuthRequest{Username: "user", Password: "secret-basic"},
+		Proxy:          &api.ProxyRequest{Server: "http://proxy.internal:3128", Username: "proxy", Password: "secret-proxy"},
+	}
+
+	redacted := req.Redacted()
+
+	data, err := json.Marshal(redacted)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-")
+
+	// What identifies the secrets is kept.
+	assert.Equal(t, "session", redacted.Cookies[0].Name)
+	assert.Equal(t, "app.example.com", redacted.Cookies[0].Domain)
+	assert.Contains(t, redacted.LocalStorage, "token")
+	assert.Contains(t, redacted.Headers, "Authorization")
+	assert.Equal(t, "user", redacted.BasicAuth.Username)
+	assert.Equal(t, "proxy", redacted.Proxy.Username)
+	assert.Equal(t, "https://hooks.example.com", redacted.WebhookUrl)
+
+	// The request itself is left alone, it's still launched with its secrets.
+	assert.Equal(t, "secret-cookie", req.Cookies[0].Value)
+	assert.Equal(t, "secret-local", req.LocalStorage["token"])
+	assert.Equal(t, "secret-header", req.Headers["Authorization"])
+	assert.Equal(t, "secret-basic", req.BasicAuth.Password)
+	assert.Equal(t, "secret-proxy", req.Proxy.Password)
+}
+
+func TestValidateReady(t *testing.T) {
+	cases := []struct {
+		name  string
+		ready *api.ReadyRequest
+		valid bool
+	}{
+		{name: "selector", ready: &api.ReadyRequest{Selector: "#video"}, valid: true},
+		{name: "every condition", ready: &api.ReadyRequest{Selector: "#video", Expression: "window.ready", NetworkIdle: "500ms", Delay: "1s", Timeout: "10s", ProceedOnTimeout: true}, valid: true},
+		{name: "nothing to wait for", ready: &api.ReadyRequest{Timeout: "10s"}, valid: false},
+		{name: "invalid network_idle", ready: &api.ReadyRequest{NetworkIdle: "soon"}, valid: false},
+		{name: "negative delay", ready: &api.ReadyRequest{Delay: "-1s"}, valid: false},
+		{name: "negative timeout", ready: &api.ReadyRequest{Selector: "#video", Timeout: "-1s"}, valid: false},
+	}
+
+	for _, c := range cases {
+		err := api.StartRecordingRequest{RecordUrl: "https://app.example.com", Ready: c.ready}.Validate()
+		assert.Equal(t, c.valid, err == nil, "%s: %v", c.name, err)
+	}
+}
+
+func TestValidateEmulation(t *testing.T) {
+	cases := []struct {
+		name      string
+		emulation *api.EmulationRequest
+		valid     bool
+	}{
+		{name: "nothing", emulation: &api.EmulationRequest{}, valid: true},
+		{name: "every setting", emulation: &api.EmulationRequest{Timezone: "Europe/Berlin", Locale: "de-DE", ColorScheme: "dark", Width: 390, Height: 844, DeviceScaleFactor: 3, Mobile: true, UserAgent: "Mozilla/5.0 (iPhone)"}, valid: true},
+		{name: "utc", emulation: &api.EmulationRequest{Timezone: "UTC"}, valid: true},
+		{name: "no preference", emulation: &api.EmulationRequest{ColorScheme: "no-preference"}, valid: true},
+		{name: "largest viewport", emulation: &api.EmulationRequest{Width: 10000, Height: 10000}, valid: true},
+		{name: "unknown timezone", emulation: &api.EmulationRequest{Timezone: "Mars/Olympus"}, valid: false},
+		{name: "local timezone", emulation: &api.EmulationRequest{Timezone: "Local"}, valid: false},
+		{name: "invalid locale", emulation: &api.EmulationRequest{Locale: "not a locale"}, valid: false},
+		{name: "unknown color scheme", emulation: &api.EmulationRequest{ColorScheme: "sepia"}, valid: false},
+		{name: "negative width", emulation: &api.EmulationRequest{Width: -1}, valid: false},
+		{name: "viewport too tall", emulation: &api.EmulationRequest{Height: 10001}, valid: false},
+		{name: "negative scale factor", emulation: &api.EmulationRequest{DeviceScaleFactor: -1}, valid: false},
+		{name: "scale factor too large", emulation: &api.EmulationRequest{DeviceScaleFactor: 11}, valid: false},
+		{name: "user agent with a line break", emulation: &api.EmulationRequest{UserAgent: "Mozilla/5.0\r\nX-Injected: 1"}, valid: false},
+	}
+
+	for _, c := range cases {
+		err := api.StartRecordingRequest{RecordUrl: "https://app.example.com", Emulation: c.emulation}.Validate()
+		assert.Equal(t, c.valid, err == nil, "%s: %v", c.name, err)
+	}
+}
+
+func TestMaxDurationIsCapped(t *testing.T) {
+	cases := []struct {
+		name      string
+		requested string
+		nodeCap   time.Duration
+		want      time.Duration
+	}{
+		{name: "no cap, no max duration", requested: "", nodeCap: 0, want: 0},
+		{name: "no cap", requested: "6h", nodeCap: 0, want: 6 * time.Hour},
+		{name: "below the cap", requested: "90m", nodeCap: 4 * time.Hour, want: 90 * time.Minute},
+		{name: "at the cap", requested: "4h", nodeCap: 4 * time.Hour, want: 4 * time.Hour},
+		{name: "above the cap is clamped", requested: "6h", nodeCap: 4 * time.Hour, want: 4 * time.Hour},
+		{name: "no max duration gets the cap", requested: "", nodeCap: 4 * time.Hour, want: 4 * time.Hour},
+	}
+
+	for _, c := range cases {
+		req := api.StartRecordingRequest{RecordUrl: "https://app.example.com", MaxDuration: c.requested}
+		assert.Equal(t, c.want, req.CappedMaxDuration(c.nodeCap), c.name)
+	}
+}
@@ -0,0 +1,13 @@
+package api
+
+import "time"
+
+// Redacted exposes the redaction of a start request to the tests.
+func (r StartRecordingRequest) Redacted() StartRecordingRequest {
+	return r.redacted()
+}
+
+// CappedMaxDuration exposes the max duration of a start request, capped at nodeCap, to the tests.
+func (r StartRecordingRequest) CappedMaxDuration(nodeCap time.Duration) time.Duration {
+	return r.maxDuration(nodeCap)
+}
@@ -0,0 +1,429 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+)
+
+type Mode string
+
+const (
+	// ModeSystem runs a single system wide daemon, the way pulseaudio.sh does.
+	ModeSystem Mode = "system"
+	// ModeUser runs a daemon for the user the service runs as.
+	ModeUser Mode = "user"
+	// ModeExternal leaves the daemon to someone else, sinks are still watched and re-created.
+	ModeExternal Mode = "external"
+)
+
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateUp       State = "up"
+	StateDown     State = "down"
+)
+
+// Status is a snapshot of the Supervisor.
+type Status struct {
+	Mode          Mode      `json:"mode"`
+	State         State     `json:"state"`
+	Restarts      int       `json:"restarts"`
+	Sinks         int       `json:"sinks"`
+	ServerVersion string    `json:"server_version,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+type SupervisorOptions struct {
+	Mode Mode
+	// CheckInterval is how often the daemon and the sinks are checked.
+	CheckInterval time.Duration
+	// StartTimeout is how long a freshly started daemon has to answer.
+	StartTimeout time.Duration
+	// SinkAttempts is how many times sink creation is tried before giving up.
+	SinkAttempts     int
+	SinkRetryBackoff time.Duration
+}
+
+// Supervisor starts and watches the PulseAudio daemon, and keeps the sinks of live pipelines in place across daemon restarts.
+type Supervisor struct {
+	mu       sync.Mutex
+	daemonMu sync.Mutex
+	// sinkMu serializes loading and unloading sinks, so a sink being removed isn't re-created behind its back.
+	sinkMu sync.Mutex
+	sinks  map[string]struct{}
+	status Status
+	log    *slog.Logger
+
+	*SupervisorOptions
+}
+
+// supervisorKey is the context key of the Supervisor, kept here so display can use it without an import cycle.
+type supervisorKey struct{}
+
+// WithSupervisor returns a copy of ctx carrying the supervisor.
+func WithSupervisor(ctx context.Context, s *Supervisor) context.Context {
+	return context.WithValue(ctx, supervisorKey{}, s)
+}
+
+// GetSupervisor retrieves the supervisor from the context, nil if there is none.
+func GetSupervisor(ctx *context.Context) *Supervisor {
+	s, _ := (*ctx).Value(supervisorKey{}).(*Supervisor)
+
+	return s
+}
+
+// NewSupervisor creates a new Supervisor.
+func NewSupervisor(opts *SupervisorOptions) *Supervisor {
+	if opts.Mode == "" {
+		opts.Mode = ModeSystem
+	}
+
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = 5 * time.Second
+	}
+
+	if opts.StartTimeout == 0 {
+		opts.StartTimeout = 10 * time.Second
+	}
+
+	if opts.SinkAttempts == 0 {
+		opts.SinkAttempts = 3
+	}
+
+	if opts.SinkRetryBackoff == 0 {
+		opts.SinkRetryBackoff = 500 * time.Millisecond
+	}
+
+	return &Supervisor{
+		sinks: make(map[string]struct{}),
+		status: Status{
+			Mode:  opts.Mode,
+			State: StateStarting,
+		},
+		log:               logger.Component("audio"),
+		SupervisorOptions: opts,
+	}
+}
+
+// Status returns a snapshot of the Supervisor.
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status
+	status.Sinks = len(s.sinks)
+
+	return status
+}
+
+// Start makes sure the daemon is up, starting it unless the mode is external.
+func (s *Supervisor) Start(ctx context.Context) error {
+	return s.ensureDaemon(ctx)
+}
+
+// Run checks the daemon and the sinks until the context is done.
+func (s *Supervisor) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("Audio supervisor stopped")
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.ensureDaemon(ctx); err != nil {
+			continue
+		}
+
+		s.restoreSinks(ctx)
+	}
+}
+
+// Probe checks the daemon answers right now, updating the status. It's safe to call on a nil Supervisor.
+func (s *Supervisor) Probe(ctx context.Context) (Status, error) {
+	if s == nil {
+		s = NewSupervisor(&SupervisorOptions{Mode: ModeExternal})
+	}
+
+	_, err := s.info(ctx)
+
+	return s.Status(), err
+}
+
+// ensureDaemon starts the daemon when it doesn't answer, and waits for it to come up.
+func (s *Supervisor) ensureDaemon(ctx context.Context) error {
+	s.daemonMu.Lock()
+	defer s.daemonMu.Unlock()
+
+	if _, err := s.info(ctx); err == nil {
+		return nil
+	} else if s.Mode == ModeExternal {
+		return err
+	}
+
+	s.log.Warn("PulseAudio daemon is not running, starting it", "mode", s.Mode)
+
+	args := []string{"-D", "--exit-idle-time=-1", "--disallow-exit"}
+
+	if s.Mode == ModeSystem {
+		args = append(args, "--system")
+	}
+
+	if out, err := exec.CommandContext(ctx, "pulseaudio", args...).CombinedOutput(); err != nil {
+		// The daemon may have come up concurrently, the wait below tells.
+		s.log.Warn("Failed to start PulseAudio daemon", "error", err, "output", strings.TrimSpace(string(out)))
+	} else {
+		metrics.PulseAudioRestarts.Inc()
+
+		s.mu.Lock()
+		s.status.Restarts++
+		s.mu.Unlock()
+	}
+
+	deadline := time.Now().Add(s.StartTimeout)
+
+	for {
+		_, err := s.info(ctx)
+
+		if err == nil {
+			s.log.Info("PulseAudio daemon is up")
+			return nil
+		}
+
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			s.log.Error("PulseAudio daemon did not come up", "error", err)
+			return err
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// info runs `pactl info`, recording whether the daemon is up.
+func (s *Supervisor) info(ctx context.Context) (map[string]string, error) {
+	out, err := exec.CommandContext(ctx, "pactl", "info").CombinedOutput()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.CheckedAt = time.Now().UTC()
+
+	if err != nil {
+		s.status.State = StateDown
+		s.status.LastError = fmt.Sprintf("pactl info: %v: %s", err, strings.TrimSpace(string(out)))
+
+		return nil, fmt.Errorf("%s", s.status.LastError)
+	}
+
+	info := ParsePactlInfo(string(out))
+
+	s.status.State = StateUp
+	s.status.LastError = ""
+	s.status.ServerVersion = info["Server Version"]
+
+	return info, nil
+}
+
+// CreateSink creates the null sink of a pipeline, retrying transient failures and starting the daemon if needed.
+// The sink is re-created if the daemon restarts, until RemoveSink. It's safe to call on a nil Supervisor, which tries once.
+func (s *Supervisor) CreateSink(ctx context.Context, name string) (string, error) {
+	if s == nil {
+		return loadSink(ctx, name)
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= s.SinkAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(s.SinkRetryBackoff * time.Duration(attempt-1)):
+			}
+
+			s.ensureDaemon(ctx)
+		}
+
+		var module string
+
+		s.sinkMu.Lock()
+
+		if module, err = loadSink(ctx, name); err == nil {
+			s.mu.Lock()
+			s.sinks[name] = struct{}{}
+			s.mu.Unlock()
+		}
+
+		s.sinkMu.Unlock()
+
+		if err == nil {
+			return module, nil
+		}
+
+		s.log.Warn("Failed to create sink", logger.PipelineKey, name, "attempt", attempt, "error", err)
+	}
+
+	return "", err
+}
+
+// RemoveSink unloads the sink of a pipeline and stops re-creating it. It's safe to call on a nil Supervisor.
+func (s *Supervisor) RemoveSink(ctx context.Context, name string) error {
+	if s != nil {
+		s.sinkMu.Lock()
+		defer s.sinkMu.Unlock()
+
+		s.mu.Lock()
+		delete(s.sinks, name)
+		s.mu.Unlock()
+	}
+
+	out, err := exec.CommandContext(ctx, "pactl", "list", "short", "modules").Output()
+
+	if err != nil {
+		return fmt.Errorf("failed to list modules: %w", err)
+	}
+
+	module, ok := FindSinkModule(string(out), name)
+
+	if !ok {
+		return nil
+	}
+
+	if out, err := exec.CommandContext(ctx, "pactl", "unload-module", module).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unload module %s: %w: %s", module, err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// restoreSinks re-creates the sinks of live pipelines that went missing, e.g. after a daemon restart.
+func (s *Supervisor) restoreSinks(ctx context.Context) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.sinks))
+
+	for name := range s.sinks {
+		names = append(names, name)
+	}
+
+	s.mu.Unlock()
+
+	if len(names) == 0 {
+		return
+	}
+
+	out, err := exec.CommandContext(ctx, "pactl", "list", "short", "sinks").Output()
+
+	if err != nil {
+		s.log.Warn("Failed to list sinks", "error", err)
+		return
+	}
+
+	present := ParseShortSinks(string(out))
+
+	for _, name := range names {
+		if !present[name] {
+			s.restoreSink(ctx, name)
+		}
+	}
+}
+
+// restoreSink re-creates a missing sink, unless its pipeline removed it since the sinks were listed.
+func (s *Supervisor) restoreSink(ctx context.Context, name string) {
+	s.sinkMu.Lock()
+	defer s.sinkMu.Unlock()
+
+	s.mu.Lock()
+	_, ok := s.sinks[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	s.log.Warn("Sink went missing, re-creating it", logger.PipelineKey, name)
+
+	if _, err := loadSink(ctx, name); err != nil {
+		s.log.Error("Failed to re-create sink", logger.PipelineKey, name, "error", err)
+	}
+}
+
+// loadSink loads a null sink and returns its module index.
+func loadSink(ctx context.Context, name string) (string, error) {
+	cmd := exec.CommandContext(ctx, "pactl",
+		"load-module", "module-null-sink",
+		fmt.Sprintf("sink_name=\"%s\"", name),
+		fmt.Sprintf("sink_properties=device.description=\"%s\"", name),
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ParsePactlInfo parses the "Key: value" lines of `pactl info`.
+func ParsePactlInfo(out string) map[string]string {
+	info := make(map[string]string)
+
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			info[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return info
+}
+
+// ParseShortSinks returns the sink names of `pactl list short sinks`.
+func ParseShortSinks(out string) map[string]bool {
+	sinks := make(map[string]bool)
+
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			sinks[fields[1]] = true
+		}
+	}
+
+	return sinks
+}
+
+// FindSinkModule returns the index of the null sink module named name in `pactl list short modules`.
+func FindSinkModule(out string, name string) (string, bool) {
+	quoted := fmt.Sprintf("sink_name=\"%s\"", name)
+	plain := fmt.Sprintf("sink_name=%s", name)
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) < 2 || fields[1] != "module-null-sink" {
+			continue
+		}
+
+		for _, arg := range fields[2:] {
+			if arg == quoted || arg == plain {
+				return fields[0], true
+			}
+		}
+	}
+
+	return "", false
+}
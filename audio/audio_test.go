@@ -0,0 +1,114 @@
+package audio_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/audio"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSupervisorDefaults(t *testing.T) {
+	s := audio.NewSupervisor(&audio.SupervisorOptions{})
+
+	status := s.Status()
+
+	assert.Equal(t, audio.ModeSystem, status.Mode)
+	assert.Equal(t, audio.StateStarting, status.State)
+	assert.Zero(t, status.Sinks)
+}
+
+func TestSupervisorContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, audio.GetSupervisor(&ctx))
+
+	s := audio.NewSupervisor(&audio.SupervisorOptions{Mode: audio.ModeExternal})
+	ctx = audio.WithSupervisor(ctx, s)
+
+	assert.Same(t, s, audio.GetSupervisor(&ctx))
+}
+
+func TestParseShortSinks(t *testing.T) {
+	sinks := audio.ParseShortSinks("0\tauto_null\tmodule-null-sink.c\ts16le 2ch 44100Hz\tIDLE\n3\tpipeline_1\tmodule-null-sink.c\ts16le 2ch 44100Hz\tRUNNING\n")
+
+	assert.True(t, sinks["pipeline_1"])
+	assert.True(t, sinks["auto_null"])
+	assert.False(t, sinks["pipeline_2"])
+}
+
+func TestParsePactlInfo(t *testing.T) {
+	info := audio.ParsePactlInfo("Server String: /run/pulse/native\nServer Name: pulseaudio\nServer Version: 16.1\nDefault Sink: auto_null\n")
+
+	assert.Equal(t, "pulseaudio", info["Server Name"])
+	assert.Equal(t, "16.1", info["Server Version"])
+	assert.Equal(t, "auto_null", info["Default Sink"])
+}
+
+func TestFindSinkModule(t *testing.T) {
+	out := "6\tmodule-native-protocol-unix\t\t\n" +
+		"21\tmodule-null-sink\tsink_name=\"grab\" sink_properties=device.description=\"grab\"\t\n" +
+		"24\tmodule-null-sink\tsink_name=\"pipeline_1\" sink_properties=device.description=\"pipeline_1\"\t\n"
+
+	module, ok := audio.FindSinkModule(out, "pipeline_1")
+
+	assert.True(t, ok)
+	assert.Equal(t, "24", module)
+
+	_, ok = audio.FindSinkModule(out, "pipeline")
+	assert.False(t, ok, "names must match exactly")
+}
+
+// fakeBinaries puts pactl and pulseaudio scripts first on the PATH. pactl info answers once the daemon is started,
+// and starting it succeeds unless the fail file exists.
+func fakeBinaries(t *testing.T) (started string, fail string) {
+	dir := t.TempDir()
+	started, fail = filepath.Join(dir, "started"), filepath.Join(dir, "fail")
+
+	scripts := map[string]string{
+		"pactl":      "#!/bin/sh\n[ -f " + started + " ] || exit 1\necho 'Server Version: 16.1'\n",
+		"pulseaudio": "#!/bin/sh\n[ -f " + fail + " ] && exit 1\ntouch " + started + "\n",
+	}
+
+	for name, script := range scripts {
+		if !assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0755)) {
+			t.FailNow()
+		}
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return started, fail
+}
+
+func TestSupervisorCountsActualRestarts(t *testing.T) {
+	_, fail := fakeBinaries(t)
+
+	s := audio.NewSupervisor(&audio.SupervisorOptions{Mode: audio.ModeUser, StartTimeout: 300 * time.Millisecond})
+	restarts := testutil.ToFloat64(metrics.PulseAudioRestarts)
+
+	// The daemon can't be started, nothing was restarted.
+	assert.NoError(t, os.WriteFile(fail, nil, 0644))
+	assert.Error(t, s.Start(context.Background()))
+	assert.Zero(t, s.Status().Restarts)
+	assert.Equal(t, restarts, testutil.ToFloat64(metrics.PulseAudioRestarts))
+
+	assert.NoError(t, os.Remove(fail))
+	assert.NoError(t, s.Start(context.Background()))
+
+	status := s.Status()
+
+	assert.Equal(t, 1, status.Restarts)
+	assert.Equal(t, restarts+1, testutil.ToFloat64(metrics.PulseAudioRestarts))
+	assert.Equal(t, audio.StateUp, status.State)
+	assert.Equal(t, "16.1", status.ServerVersion)
+
+	// A daemon already up isn't restarted.
+	assert.NoError(t, s.Start(context.Background()))
+	assert.Equal(t, 1, s.Status().Restarts)
+	assert.Equal(t, restarts+1, testutil.ToFloat64(metrics.PulseAudioRestarts))
+}
@@ -128,13 +128,18 @@ func (a *AwsClient) CompletePartUpload(input *CloudUploadPartInput) (*CloudUploa
 		return nil, fmt.Errorf("failed to complete multipart upload: %v", err)
 	}
 
-	recordingUrl := fmt.Sprintf("https://%s.%s/%s", a.bucketName, env.GetBucketEndpoint(), *input.StoragePath)
+	recordingUrl := a.ObjectUrl(input.StoragePath)
 
 	return &CloudUploadPartCompleted{
 		Recording_Url: &recordingUrl,
 	}, nil
 }
 
+// ObjectUrl returns the URL the object is served at.
+func (a *AwsClient) ObjectUrl(storagePath *string) string {
+	return fmt.Sprintf("https://%s.%s/%s", a.bucketName, env.GetBucketEndpoint(), *storagePath)
+}
+
 // UploadFile uploads the file to the cloud, using AWS Uploader which streams the file to the cloud.
 func (a *AwsClient) UploadFile(fileName *string, filePath string) error {
 	file, err := os.Open(filePath)
@@ -167,3 +172,16 @@ func (a *AwsClient) DownloadFile(fileName *string, downloadPath string) error {
 
 	return err
 }
+
+// Ping checks the bucket is reachable, by asking for its metadata.
+func (a *AwsClient) Ping(ctx context.Context) error {
+	_, err := a.s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(a.bucketName),
+	})
+
+	if err != nil {
+		return fmt.Errorf("bucket %s is not reachable: %w", a.bucketName, err)
+	}
+
+	return nil
+}
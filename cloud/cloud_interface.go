@@ -19,6 +19,10 @@ type CloudClient interface {
 	CompletePartUpload(input *CloudUploadPartInput) (*CloudUploadPartCompleted, error)
 	UploadFile(fileName *string, filePath string) error
 	DownloadFile(fileName *string, downloadPath string) error
+	// ObjectUrl returns the URL the object is served at.
+	ObjectUrl(storagePath *string) string
+	// Ping checks the bucket can be reached with the configured credentials.
+	Ping(ctx context.Context) error
 }
 
 type CloudUploadPartInput struct {
@@ -2,20 +2,44 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/OmGuptaIND/api"
+	"github.com/OmGuptaIND/audio"
 	"github.com/OmGuptaIND/cloud"
 	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/drain"
 	"github.com/OmGuptaIND/env"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/executor"
+	"github.com/OmGuptaIND/health"
+	"github.com/OmGuptaIND/hls"
+	"github.com/OmGuptaIND/logger"
 	"github.com/OmGuptaIND/pkg"
+	"github.com/OmGuptaIND/postprocess"
+	"github.com/OmGuptaIND/queue"
+	"github.com/OmGuptaIND/scheduler"
 	store "github.com/OmGuptaIND/store"
+	"github.com/OmGuptaIND/webhook"
 )
 
 func main() {
 	env.LoadEnvironmentVariables()
+
+	logBuffers := logger.NewBuffers(env.GetLogBufferLines(), 100)
+
+	logger.Setup(logger.Options{
+		Format:  env.GetLogFormat(),
+		Level:   logger.ParseLevel(env.GetLogLevel()),
+		Buffers: logBuffers,
+	})
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -24,10 +48,141 @@ func main() {
 	cloudClient, err := cloud.NewAwsClient(ctx, &cloud.AwsClientOptions{})
 
 	if err != nil {
-		log.Fatalf("Failed to create cloud client: %v", err)
+		fatal("Failed to create cloud client", err)
+	}
+
+	drainer := drain.NewDrainer(&drain.DrainerOptions{
+		Timeout: env.GetDrainTimeout(),
+		Store:   appStore,
+	})
+
+	jobExecutor := executor.NewWorkerExecutor(ctx, &executor.WorkerExecutorOptions{
+		MaxRetries:   1,
+		WorkerCount:  2,
+		RetryBackoff: 5 * time.Second,
+	})
+
+	jobExecutor.Start()
+
+	// Post-processing gets its own workers, a long job must not hold back queued starts.
+	postExecutor := executor.NewWorkerExecutor(ctx, &executor.WorkerExecutorOptions{
+		MaxRetries:   1,
+		WorkerCount:  max(env.GetPostprocessWorkers(), 1),
+		RetryBackoff: 10 * time.Second,
+	})
+
+	postExecutor.Start()
+
+	startQueue, err := queue.NewQueue(&queue.QueueOptions{
+		Path: filepath.Join(env.GetDataDir(), "queue.json"),
+		Reserve: func() bool {
+			return appStore.Reserve(env.GetMaxPipelines())
+		},
+		Paused:   drainer.Draining,
+		Executor: jobExecutor,
+	})
+
+	if err != nil {
+		fatal("Failed to create start queue", err)
+	}
+
+	recordingScheduler, err := scheduler.NewScheduler(&scheduler.SchedulerOptions{
+		Path:   filepath.Join(env.GetDataDir(), "schedules.json"),
+		Paused: drainer.Draining,
+	})
+
+	if err != nil {
+		fatal("Failed to create scheduler", err)
+	}
+
+	audioSupervisor := audio.NewSupervisor(&audio.SupervisorOptions{
+		Mode:          audio.Mode(env.GetAudioMode()),
+		CheckInterval: env.GetAudioCheckInterval(),
+	})
+
+	// A daemon that doesn't come up fails readiness rather than the node, the supervisor keeps trying.
+	if err := audioSupervisor.Start(ctx); err != nil {
+		slog.Error("PulseAudio is not available", "error", err)
+	}
+
+	go audioSupervisor.Run(ctx)
+
+	eventBus := events.NewBus()
+
+	notifier, err := webhook.NewNotifier(&webhook.NotifierOptions{
+		Path: filepath.Join(env.GetDataDir(), "webhooks.json"),
+		Global: webhook.Target{
+			URL:    env.GetWebhookUrl(),
+			Secret: env.GetWebhookSecret(),
+		},
+		MaxAttempts: env.GetWebhookMaxAttempts(),
+	})
+
+	if err != nil {
+		fatal("Failed to create webhook notifier", err)
+	}
+
+	go notifier.Run(ctx, eventBus)
+
+	if f := env.GetOutputFormat(); f != "mp4" && f != "hls" {
+		fatal("Invalid OUTPUT_FORMAT", fmt.Errorf("%q must be mp4 or hls", f))
+	}
+
+	hlsDefaults := hls.Options{
+		SegmentType:     hls.SegmentType(env.GetHLSSegmentType()),
+		SegmentDuration: env.GetHLSSegmentDuration(),
+	}
+
+	if err := hlsDefaults.Validate(); err != nil {
+		fatal("Invalid HLS_SEGMENT_TYPE or HLS_SEGMENT_DURATION", err)
+	}
+
+	faststart, err := postprocess.ParseFaststartMode(env.GetFaststart())
+
+	if err != nil {
+		fatal("Invalid FASTSTART", err)
+	}
+
+	processor, err := postprocess.NewProcessor(ctx, &postprocess.ProcessorOptions{
+		Executor:  postExecutor,
+		Client:    cloudClient,
+		Bus:       eventBus,
+		Dir:       filepath.Join(config.RECORDING_DIR, "postprocess"),
+		MaxQueued: env.GetPostprocessQueueSize(),
+		Faststart: faststart,
+		Previews: postprocess.PreviewOptions{
+			Interval: env.GetPreviewInterval(),
+			Width:    env.GetPreviewWidth(),
+		},
+	})
+
+	if err != nil {
+		fatal("Failed to create post-processor", err)
 	}
 
-	appCtx := createAppContext(ctx, appStore, cloudClient)
+	checker := health.NewChecker(&health.CheckerOptions{CacheFor: 2 * time.Second},
+		health.PulseAudio(audioSupervisor),
+		health.Binary("ffmpeg", "ffmpeg", "-version"),
+		health.Binary("xvfb", "Xvfb", "-version"),
+		health.Binary("chromium", "chromium", "--version"),
+		health.DiskSpace("disk", config.RECORDING_DIR, env.GetMinFreeDiskBytes()),
+		health.Storage(cloudClient.Ping),
+		health.Drain(drainer.Draining),
+	)
+
+	appCtx := createAppContext(ctx, appServices{
+		store:     appStore,
+		client:    cloudClient,
+		queue:     startQueue,
+		drainer:   drainer,
+		scheduler: recordingScheduler,
+		bus:       eventBus,
+		notifier:  notifier,
+		logs:      logBuffers,
+		health:    checker,
+		audio:     audioSupervisor,
+		processor: processor,
+	})
 
 	apiServer := api.NewApiServer(appCtx, api.ApiServerOptions{
 		Port: 3000,
@@ -35,27 +190,72 @@ func main() {
 
 	apiServer.Start()
 
+	go startQueue.Run(ctx)
+	go recordingScheduler.Run(ctx)
+
 	// Handle signals
 	sig := pkg.HandleSignal()
 
 	go func() {
 		for val := range sig {
 			if val == syscall.SIGINT || val == syscall.SIGTERM {
-				log.Println("Shutting down...")
-				cancel()
-				signal.Stop(sig)
-				return
+				// A second signal while draining skips the wait.
+				if drainer.Draining() {
+					slog.Info("Received second signal, shutting down without waiting for pipelines")
+					cancel()
+					signal.Stop(sig)
+					return
+				}
+
+				slog.Info("Draining before shutdown")
+				drainer.Start()
 			}
 		}
 	}()
 
+	go func() {
+		<-drainer.Done()
+		slog.Info("Shutting down")
+		cancel()
+	}()
+
 	<-apiServer.Done()
 }
 
-// CreateGlobalContext creates a new context with the provided store and chunker
-func createAppContext(ctx context.Context, store *store.AppStore, client cloud.CloudClient) context.Context {
-	ctx = context.WithValue(ctx, config.StoreKey, store)
-	ctx = context.WithValue(ctx, config.CloudClientKey, client)
+// appServices holds the node-wide services shared through the app context.
+type appServices struct {
+	store     *store.AppStore
+	client    cloud.CloudClient
+	queue     *queue.Queue
+	drainer   *drain.Drainer
+	scheduler *scheduler.Scheduler
+	bus       *events.Bus
+	notifier  *webhook.Notifier
+	logs      *logger.Buffers
+	health    *health.Checker
+	audio     *audio.Supervisor
+	processor *postprocess.Processor
+}
+
+// CreateGlobalContext creates a new context carrying the provided services
+func createAppContext(ctx context.Context, services appServices) context.Context {
+	ctx = context.WithValue(ctx, config.StoreKey, services.store)
+	ctx = context.WithValue(ctx, config.CloudClientKey, services.client)
+	ctx = context.WithValue(ctx, config.QueueKey, services.queue)
+	ctx = context.WithValue(ctx, config.DrainerKey, services.drainer)
+	ctx = context.WithValue(ctx, config.SchedulerKey, services.scheduler)
+	ctx = context.WithValue(ctx, config.WebhookKey, services.notifier)
+	ctx = context.WithValue(ctx, config.HealthKey, services.health)
+	ctx = context.WithValue(ctx, config.PostprocessKey, services.processor)
+	ctx = events.WithBus(ctx, services.bus)
+	ctx = logger.WithBuffers(ctx, services.logs)
+	ctx = audio.WithSupervisor(ctx, services.audio)
 
 	return ctx
 }
+
+// fatal logs an error the node can't start without and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
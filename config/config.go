@@ -20,6 +20,12 @@ const (
 	StoreKey       ContextKey = "store"
 	CloudClientKey ContextKey = "client"
 	ChunkerKey     ContextKey = "chunker"
+	QueueKey       ContextKey = "queue"
+	DrainerKey     ContextKey = "drainer"
+	SchedulerKey   ContextKey = "scheduler"
+	WebhookKey     ContextKey = "webhook"
+	HealthKey      ContextKey = "health"
+	PostprocessKey ContextKey = "postprocess"
 )
 
 // ChunkInfo represents the information of a chunk, to be used by the Watcher.
@@ -0,0 +1,268 @@
+package display
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// DefaultActionTimeout bounds an Action that sets no Timeout.
+const DefaultActionTimeout = 10 * time.Second
+
+// MaxActions caps the length of an action script.
+const MaxActions = 50
+
+// MaxActionDuration caps the Duration of a sleep and the Timeout of any step.
+const MaxActionDuration = time.Minute
+
+// ScriptTimeout bounds a whole action script, whatever its steps.
+const ScriptTimeout = 5 * time.Minute
+
+type ActionType string
+
+const (
+	ActionNavigate ActionType = "navigate"
+	ActionClick    ActionType = "click"
+	ActionTypeText ActionType = "type"
+	ActionPress    ActionType = "press"
+	ActionWaitFor  ActionType = "wait_for"
+	ActionEvaluate ActionType = "evaluate"
+	ActionSleep    ActionType = "sleep"
+	ActionScroll   ActionType = "scroll"
+)
+
+// keys maps the key names an Action can press to their chromedp keys, other keys are typed as is.
+var keys = map[string]string{
+	"Enter":      kb.Enter,
+	"Tab":        kb.Tab,
+	"Escape":     kb.Escape,
+	"Backspace":  kb.Backspace,
+	"Delete":     kb.Delete,
+	"Space":      " ",
+	"ArrowDown":  kb.ArrowDown,
+	"ArrowLeft":  kb.ArrowLeft,
+	"ArrowRight": kb.ArrowRight,
+	"ArrowUp":    kb.ArrowUp,
+	"End":        kb.End,
+	"Home":       kb.Home,
+	"PageDown":   kb.PageDown,
+	"PageUp":     kb.PageUp,
+}
+
+// Action is a single step of a script run against the page.
+type Action struct {
+	Type ActionType
+
+	// Selector is the CSS selector clicked, typed into, waited for or scrolled into view.
+	Selector string
+	URL      string
+	Text     string
+	// Key is a key name like "Enter" or "Escape", pressed on Selector or the focused element.
+	Key        string
+	Expression string
+	// Duration is how long a sleep lasts.
+	Duration time.Duration
+	// X and Y scroll the window by, when there is no Selector to scroll into view.
+	X int
+	Y int
+
+	// Timeout bounds the step, it defaults to DefaultActionTimeout.
+	Timeout time.Duration
+	// Optional lets the script carry on when the step fails.
+	Optional bool
+}
+
+// ActionResult is the outcome of a single step.
+type ActionResult struct {
+	Index      int             `json:"index"`
+	Type       ActionType      `json:"type"`
+	Error      string          `json:"error,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+}
+
+// Validate checks the step has what its type needs.
+func (a Action) Validate() error {
+	switch a.Type {
+	case ActionNavigate:
+		if a.URL == "" {
+			return fmt.Errorf("navigate needs a url")
+		}
+	case ActionClick, ActionWaitFor:
+		if a.Selector == "" {
+			return fmt.Errorf("%s needs a selector", a.Type)
+		}
+	case ActionTypeText:
+		if a.Selector == "" || a.Text == "" {
+			return fmt.Errorf("type needs a selector and a text")
+		}
+	case ActionPress:
+		if a.Key == "" {
+			return fmt.Errorf("press needs a key")
+		}
+	case ActionEvaluate:
+		if a.Expression == "" {
+			return fmt.Errorf("evaluate needs an expression")
+		}
+	case ActionSleep:
+		if a.Duration <= 0 {
+			return fmt.Errorf("sleep needs a positive duration")
+		}
+
+		if a.Duration > MaxActionDuration {
+			return fmt.Errorf("sleep can't last more than %s", MaxActionDuration)
+		}
+	case ActionScroll:
+		if a.Selector == "" && a.X == 0 && a.Y == 0 {
+			return fmt.Errorf("scroll needs a selector or an x/y offset")
+		}
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+
+	if a.Timeout < 0 {
+		return fmt.Errorf("timeout can't be negative")
+	}
+
+	if a.Timeout > MaxActionDuration {
+		return fmt.Errorf("timeout can't be more than %s", MaxActionDuration)
+	}
+
+	return nil
+}
+
+// ValidateActions checks every step of a script.
+func ValidateActions(actions []Action) error {
+	if len(actions) > MaxActions {
+		return fmt.Errorf("at most %d actions are allowed", MaxActions)
+	}
+
+	for i, a := range actions {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("action %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// RunActions runs the script against the page, step by step. It stops at the first failed step that isn't Optional,
+// returning the results so far along with the error.
+func (d *Display) RunActions(actions []Action) ([]ActionResult, error) {
+	if d.browser == nil {
+		return nil, fmt.Errorf("chrome is not running")
+	}
+
+	return d.runActions(d.browser.chromeCtx, actions)
+}
+
+// runActions runs the script within ScriptTimeout, so a script can't hold the page, nor the lock of its caller, for long.
+func (d *Display) runActions(ctx context.Context, actions []Action) ([]ActionResult, error) {
+	// The watchdog runs the launch script again on a recovered page, never alongside another script.
+	d.actionMtx.Lock()
+	defer d.actionMtx.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, ScriptTimeout)
+	defer cancel()
+
+	results := make([]ActionResult, 0, len(actions))
+
+	for i, a := range actions {
+		start := time.Now()
+		value, err := runAction(ctx, a)
+
+		result := ActionResult{
+			Index:      i,
+			Type:       a.Type,
+			Value:      value,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+
+		if err == nil {
+			d.log.Info("Action done", "index", i, "type", a.Type, "duration_ms", result.DurationMs)
+			continue
+		}
+
+		if a.Optional {
+			d.log.Warn("Optional action failed, carrying on", "index", i, "type", a.Type, "error", err)
+			continue
+		}
+
+		d.log.Error("Action failed", "index", i, "type", a.Type, "error", err)
+
+		return results, fmt.Errorf("action %d (%s) failed: %w", i, a.Type, err)
+	}
+
+	return results, nil
+}
+
+// runAction runs a single step within its timeout, returning the value of an evaluate.
+func runAction(ctx context.Context, a Action) (json.RawMessage, error) {
+	if a.Type == ActionSleep {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(a.Duration):
+			return nil, nil
+		}
+	}
+
+	timeout := a.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultActionTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch a.Type {
+	case ActionNavigate:
+		return nil, chromedp.Run(ctx, chromedp.Navigate(a.URL))
+	case ActionClick:
+		return nil, chromedp.Run(ctx, chromedp.Click(a.Selector, chromedp.ByQuery))
+	case ActionTypeText:
+		return nil, chromedp.Run(ctx, chromedp.SendKeys(a.Selector, a.Text, chromedp.ByQuery))
+	case ActionPress:
+		key, ok := keys[a.Key]
+
+		if !ok {
+			key = a.Key
+		}
+
+		if a.Selector != "" {
+			return nil, chromedp.Run(ctx, chromedp.SendKeys(a.Selector, key, chromedp.ByQuery))
+		}
+
+		return nil, chromedp.Run(ctx, chromedp.KeyEvent(key))
+	case ActionWaitFor:
+		return nil, chromedp.Run(ctx, chromedp.WaitVisible(a.Selector, chromedp.ByQuery))
+	case ActionEvaluate:
+		var value []byte
+
+		err := chromedp.Run(ctx, chromedp.Evaluate(a.Expression, &value, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}))
+
+		return value, err
+	case ActionScroll:
+		if a.Selector != "" {
+			return nil, chromedp.Run(ctx, chromedp.ScrollIntoView(a.Selector, chromedp.ByQuery))
+		}
+
+		return nil, chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf("window.scrollBy(%d, %d)", a.X, a.Y), nil))
+	}
+
+	return nil, fmt.Errorf("unknown action type %q", a.Type)
+}
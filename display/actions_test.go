@@ -0,0 +1,21 @@
+package display_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateActions(t *testing.T) {
+	assert.NoError(t, display.ValidateActions([]display.Action{
+		{Type: display.ActionSleep, Duration: display.MaxActionDuration},
+		{Type: display.ActionClick, Selector: "#play", Timeout: display.MaxActionDuration},
+	}))
+
+	assert.Error(t, display.ValidateActions([]display.Action{{Type: display.ActionSleep}}))
+	assert.Error(t, display.ValidateActions([]display.Action{{Type: display.ActionSleep, Duration: time.Hour}}))
+	assert.Error(t, display.ValidateActions([]display.Action{{Type: display.ActionClick, Selector: "#play", Timeout: time.Hour}}))
+	assert.Error(t, display.ValidateActions(make([]display.Action, display.MaxActions+1)))
+}
@@ -1,16 +1,21 @@
 package display
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/OmGuptaIND/audio"
+	"github.com/OmGuptaIND/logger"
 	"github.com/OmGuptaIND/pkg"
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 )
 
@@ -21,14 +26,46 @@ type DisplayOptions struct {
 	Width  int
 	Height int
 	Depth  int
+
+	// Audio creates the Pulse Sink, retrying transient failures and re-creating it if the daemon restarts.
+	Audio *audio.Supervisor
+
+	// Watchdog reloads the page when the renderer crashes, hangs or freezes.
+	Watchdog WatchdogOptions
+
+	// Actions are run against the page once it has loaded, before it's recorded.
+	Actions []Action
+
+	// Session holds the cookies, storage, headers and credentials applied before the page is loaded.
+	Session Session
+
+	// Emulation overrides the timezone, locale, color scheme, device metrics and user agent of the page.
+	Emulation Emulation
+
+	// Proxy routes the traffic of the browser through an HTTP or SOCKS proxy.
+	Proxy Proxy
+	// Network blocks, or only allows, requests by URL.
+	Network NetworkRules
+
+	// RecordHAR records the network activity of the page as an HTTP Archive.
+	RecordHAR bool
 }
 
 type Display struct {
 	pulseSink string
 	DisplayId string
 
+	// ActionResults are the outcomes of the Actions run at launch.
+	ActionResults []ActionResult
+	actionMtx     sync.Mutex
+
+	// blocked counts the requests failed by the Network rules.
+	blocked atomic.Int64
+	har     *harRecorder
+
 	xvfb    *exec.Cmd
 	browser *chromeDisplay
+	log     *slog.Logger
 
 	*DisplayOptions
 }
@@ -40,9 +77,12 @@ type chromeDisplay struct {
 
 // NewDisplay initializes a new Display with the specified options.
 func NewDisplay(opts DisplayOptions) *Display {
+	displayId := pkg.RandomDisplay()
+
 	return &Display{
-		DisplayId:      pkg.RandomDisplay(),
+		DisplayId:      displayId,
 		pulseSink:      "",
+		log:            logger.Pipeline(opts.ID, "display").With(logger.DisplayKey, displayId),
 		DisplayOptions: &opts,
 	}
 }
@@ -77,7 +117,7 @@ func (d *Display) Launch(url string) error {
 		return err
 	}
 
-	log.Println("Chrome launched successfully")
+	d.log.Info("Chrome launched successfully")
 
 	return nil
 }
@@ -85,11 +125,11 @@ func (d *Display) Launch(url string) error {
 // Start launches the Xvfb server with the specified display.
 func (d *Display) LaunchXvfb() error {
 	if d.xvfb != nil {
-		log.Println("Xvfb server is already running")
+		d.log.Info("Xvfb server is already running")
 		return nil
 	}
 
-	log.Println("Starting Xvfb server...")
+	d.log.Info("Starting Xvfb server")
 
 	dims := fmt.Sprintf("%dx%dx%d", d.Width, d.Height, d.Depth)
 	xvfb := exec.Command("Xvfb", d.DisplayId, "-screen", "0", dims, "-ac", "-nolisten", "tcp")
@@ -98,7 +138,7 @@ func (d *Display) LaunchXvfb() error {
 	}
 	d.xvfb = xvfb
 
-	log.Println("Xvfb server started")
+	d.log.Info("Xvfb server started")
 
 	return nil
 }
@@ -106,43 +146,37 @@ func (d *Display) LaunchXvfb() error {
 // Start a new Pulse Sink
 func (d *Display) LaunchPulseSink() error {
 	if d.pulseSink != "" {
-		log.Println("Pulse Sink is already running")
+		d.log.Info("Pulse Sink is already running")
 		return nil
 	}
 
-	log.Println("Starting Pulse Sink...", d.ID)
+	d.log.Info("Starting Pulse Sink")
 
-	cmd := exec.Command("pactl",
-		"load-module", "module-null-sink",
-		fmt.Sprintf("sink_name=\"%s\"", d.ID),
-		fmt.Sprintf("sink_properties=device.description=\"%s\"", d.ID),
-	)
+	sink, err := d.Audio.CreateSink(context.Background(), d.ID)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		log.Println("Failed to start Pulse Sink", err)
+	if err != nil {
+		d.log.Error("Failed to start Pulse Sink", "error", err)
 		return err
 	}
 
-	d.pulseSink = strings.TrimSpace(stdout.String())
+	d.pulseSink = sink
 
-	log.Println("Pulse Sink started", d.pulseSink)
+	d.log.Info("Pulse Sink started", "sink", d.pulseSink)
 
 	return nil
 }
 
 // LaunchChrome starts Chrome with the specified URL.
 func (d *Display) LaunchChrome(url string) (*chromeDisplay, error) {
-	log.Println("Launching Chrome...")
 	if d.browser != nil {
-		log.Println("Chrome is already running")
+		d.log.Info("Chrome is already running")
 		return d.browser, nil
 	}
 
-	log.Println("Starting Chrome...", d.DisplayId)
+	d.log.Info("Starting Chrome")
+
+	// Chrome's own output is kept with the pipeline logs, at debug level.
+	chromeOutput := logger.Writer(d.log.With(logger.ComponentKey, "chrome"), slog.LevelDebug)
 
 	opts := []chromedp.ExecAllocatorOption{
 		chromedp.ExecPath("chromium"),
@@ -184,17 +218,58 @@ func (d *Display) LaunchChrome(url string) (*chromeDisplay, error) {
 		chromedp.Flag("window-size", fmt.Sprintf("%d,%d", d.Width, d.Height)),
 		chromedp.Flag("display", d.DisplayId),
 		chromedp.Env(fmt.Sprintf("PULSE_SINK=%s", d.ID)),
+		chromedp.CombinedOutput(chromeOutput),
 	}
 
+	opts = append(opts, d.proxyOptions()...)
+
 	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
 
 	ctx, cancel := chromedp.NewContext(allocCtx)
 
-	err := chromedp.Run(ctx, chromedp.Navigate(url), chromedp.Evaluate(`window.screen.width`, &d.Width),
-		chromedp.Evaluate(`window.screen.height`, &d.Height))
+	chromedp.ListenTarget(ctx, d.logConsole)
+
+	if d.RecordHAR {
+		// Headers set by the session are secrets, like the credentials.
+		d.har = newHARRecorder(d.Session.Headers)
+		chromedp.ListenTarget(ctx, d.har.listen)
+	}
+
+	var w *watchdog
+
+	if d.Watchdog.HeartbeatInterval > 0 {
+		w = newWatchdog(d, url, &d.Watchdog)
+		chromedp.ListenTarget(ctx, w.listen)
+	}
+
+	err := d.applySession(ctx, url)
+
+	if err == nil {
+		err = d.applyInterception(ctx, url)
+	}
+
+	if err == nil {
+		err = d.applyEmulation(ctx)
+	}
+
+	if err == nil {
+		err = chromedp.Run(ctx, chromedp.Navigate(url))
+	}
+
+	// Emulated device metrics change what the page reports as its screen, the window keeps the size of the display.
+	if err == nil && !d.Emulation.metrics() {
+		err = chromedp.Run(ctx, chromedp.Evaluate(`window.screen.width`, &d.Width), chromedp.Evaluate(`window.screen.height`, &d.Height))
+	}
+
+	if err == nil && len(d.Actions) > 0 {
+		d.ActionResults, err = d.runActions(ctx, d.Actions)
+	}
 
 	if err != nil {
-		log.Println("Failed to start Chrome", err)
+		d.log.Error("Failed to start Chrome", "error", err)
+		cancel()
+		cancelAlloc()
+		chromeOutput.Close()
 		return nil, err
 	}
 
@@ -205,17 +280,78 @@ func (d *Display) LaunchChrome(url string) (*chromeDisplay, error) {
 
 	d.browser = chromeDisplay
 
+	if w != nil {
+		d.Wg.Add(1)
+		go func() {
+			defer d.Wg.Done()
+			w.run(ctx)
+		}()
+	}
+
 	go func() {
 		<-chromeDisplay.chromeCtx.Done()
-		log.Println("Chrome context done")
+		d.log.Info("Chrome context done")
 		cancelAlloc()
+		chromeOutput.Close()
 	}()
 
-	log.Println("Chrome Launched")
+	d.log.Info("Chrome Launched")
 
 	return chromeDisplay, nil
 }
 
+// logConsole keeps the page console output, uncaught exceptions and browser log entries with the pipeline logs.
+// Errors are logged as warnings, everything else at debug level.
+func (d *Display) logConsole(ev any) {
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		level := slog.LevelDebug
+
+		if ev.Type == runtime.APITypeError || ev.Type == runtime.APITypeAssert {
+			level = slog.LevelWarn
+		}
+
+		d.log.Log(context.Background(), level, consoleText(ev.Args), logger.ComponentKey, "chrome", "source", "console", "type", ev.Type.String())
+	case *runtime.EventExceptionThrown:
+		details := ev.ExceptionDetails
+		message := details.Text
+
+		if details.Exception != nil && details.Exception.Description != "" {
+			message = details.Exception.Description
+		}
+
+		d.log.Warn(message, logger.ComponentKey, "chrome", "source", "exception", "url", details.URL, "line", details.LineNumber+1, "column", details.ColumnNumber+1)
+	case *cdplog.EventEntryAdded:
+		level := slog.LevelDebug
+
+		if ev.Entry.Level == cdplog.LevelError {
+			level = slog.LevelWarn
+		}
+
+		d.log.Log(context.Background(), level, ev.Entry.Text, logger.ComponentKey, "chrome", "source", ev.Entry.Source.String(), "url", ev.Entry.URL)
+	}
+}
+
+// consoleText joins the arguments of a console call the way the console prints them.
+func consoleText(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		var text string
+
+		switch {
+		case arg.Value != nil && json.Unmarshal(arg.Value, &text) == nil:
+			parts = append(parts, text)
+		case arg.Value != nil:
+			parts = append(parts, string(arg.Value))
+		default:
+			parts = append(parts, arg.Description)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
 // Close stops the Chrome instance.
 func (c *chromeDisplay) Close() {
 	c.chromeCancel()
@@ -223,7 +359,7 @@ func (c *chromeDisplay) Close() {
 
 // Close stops the Chrome instance for the specified URL.
 func (d *Display) CloseChrome(id string) {
-	log.Println("Closing Chrome...")
+	d.log.Info("Closing Chrome")
 
 	if d.browser != nil {
 		d.browser.chromeCancel()
@@ -232,7 +368,7 @@ func (d *Display) CloseChrome(id string) {
 
 // Close stops the Xvfb server and Chrome.
 func (d *Display) Close() {
-	log.Println("Closing display...")
+	d.log.Info("Closing display")
 
 	if d.browser != nil {
 		d.browser.chromeCancel()
@@ -246,21 +382,21 @@ func (d *Display) Close() {
 // CloseXvfb stops the Xvfb server.
 func (d *Display) CloseXvfb() {
 	defer d.Wg.Done()
-	log.Println("Closing Xvfb server...")
+	d.log.Info("Closing Xvfb server")
 
 	if d.xvfb != nil {
 		err := d.xvfb.Process.Signal(os.Interrupt)
 
 		if err != nil {
-			log.Println("Failed to stop Xvfb server")
+			d.log.Warn("Failed to stop Xvfb server", "error", err)
 		}
 
 		err = d.xvfb.Wait()
 
 		if err != nil {
-			log.Println("Xvfb server exited with error", err)
+			d.log.Warn("Xvfb server exited with error", "error", err)
 		} else {
-			log.Println("Xvfb server stopped")
+			d.log.Info("Xvfb server stopped")
 		}
 
 		d.xvfb = nil
@@ -270,15 +406,14 @@ func (d *Display) CloseXvfb() {
 // ClosePulseSink stops the Pulse Sink.
 func (d *Display) ClosePulseSink() {
 	defer d.Wg.Done()
-	log.Println("Closing Pulse Sink...")
+	d.log.Info("Closing Pulse Sink")
 
 	if d.pulseSink != "" {
-		cmd := exec.Command("pactl", "unload-module", d.pulseSink)
-		if err := cmd.Run(); err != nil {
-			log.Printf("Failed to stop Pulse Sink, Sink: %s\n", d.pulseSink)
+		if err := d.Audio.RemoveSink(context.Background(), d.ID); err != nil {
+			d.log.Warn("Failed to stop Pulse Sink", "sink", d.pulseSink, "error", err)
 		}
 
-		log.Println("Pulse Sink stopped")
+		d.log.Info("Pulse Sink stopped")
 
 		d.pulseSink = ""
 	}
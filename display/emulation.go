@@ -0,0 +1,140 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/text/language"
+)
+
+// maxViewport bounds the emulated viewport, in CSS pixels.
+const maxViewport = 10000
+
+type ColorScheme string
+
+const (
+	ColorSchemeLight        ColorScheme = "light"
+	ColorSchemeDark         ColorScheme = "dark"
+	ColorSchemeNoPreference ColorScheme = "no-preference"
+)
+
+// Emulation makes the page render as another device, locale or timezone would. Zero values leave the browser defaults.
+type Emulation struct {
+	// Timezone is an IANA timezone like "Europe/Berlin".
+	Timezone string
+	// Locale is a BCP 47 language tag like "de-DE", it's also sent as the Accept-Language header.
+	Locale      string
+	ColorScheme ColorScheme
+
+	// Width and Height override the viewport, 0 keeps the size of the window.
+	Width             int
+	Height            int
+	DeviceScaleFactor float64
+	Mobile            bool
+
+	UserAgent string
+}
+
+// Enabled reports whether there is anything to emulate.
+func (e Emulation) Enabled() bool {
+	return e.Timezone != "" || e.Locale != "" || e.ColorScheme != "" || e.metrics() || e.UserAgent != ""
+}
+
+// metrics reports whether the device metrics are overridden.
+func (e Emulation) metrics() bool {
+	return e.Width > 0 || e.Height > 0 || e.DeviceScaleFactor > 0 || e.Mobile
+}
+
+// Validate checks every setting before Chrome is launched, so a typo fails the request instead of the recording.
+func (e Emulation) Validate() error {
+	if e.Timezone != "" {
+		if _, err := time.LoadLocation(e.Timezone); err != nil || e.Timezone == "Local" {
+			return fmt.Errorf("unknown timezone %q", e.Timezone)
+		}
+	}
+
+	if e.Locale != "" {
+		if _, err := language.Parse(e.Locale); err != nil {
+			return fmt.Errorf("invalid locale %q: %w", e.Locale, err)
+		}
+	}
+
+	switch e.ColorScheme {
+	case "", ColorSchemeLight, ColorSchemeDark, ColorSchemeNoPreference:
+	default:
+		return fmt.Errorf("color scheme must be light, dark or no-preference")
+	}
+
+	if e.Width < 0 || e.Width > maxViewport || e.Height < 0 || e.Height > maxViewport {
+		return fmt.Errorf("width and height must be between 0 and %d", maxViewport)
+	}
+
+	if e.DeviceScaleFactor < 0 || e.DeviceScaleFactor > 10 {
+		return fmt.Errorf("device scale factor must be between 0 and 10")
+	}
+
+	if strings.ContainsAny(e.UserAgent, "\r\n") {
+		return fmt.Errorf("user agent can't contain line breaks")
+	}
+
+	return nil
+}
+
+// applyEmulation overrides the tab settings, it has to run before the first navigation.
+func (d *Display) applyEmulation(ctx context.Context) error {
+	e := d.Emulation
+
+	if !e.Enabled() {
+		return nil
+	}
+
+	actions := make([]chromedp.Action, 0, 5)
+
+	if e.Timezone != "" {
+		actions = append(actions, emulation.SetTimezoneOverride(e.Timezone))
+	}
+
+	if e.Locale != "" {
+		actions = append(actions, emulation.SetLocaleOverride().WithLocale(e.Locale))
+	}
+
+	if e.ColorScheme != "" {
+		actions = append(actions, emulation.SetEmulatedMedia().WithFeatures([]*emulation.MediaFeature{
+			{Name: "prefers-color-scheme", Value: string(e.ColorScheme)},
+		}))
+	}
+
+	if e.metrics() {
+		actions = append(actions, emulation.SetDeviceMetricsOverride(int64(e.Width), int64(e.Height), e.DeviceScaleFactor, e.Mobile))
+	}
+
+	if e.UserAgent != "" || e.Locale != "" {
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			userAgent := e.UserAgent
+
+			// The user agent can't be left out of the override, the browser's own one is kept when only the locale is set.
+			if userAgent == "" {
+				if err := chromedp.Evaluate(`navigator.userAgent`, &userAgent).Do(ctx); err != nil {
+					return err
+				}
+			}
+
+			override := emulation.SetUserAgentOverride(userAgent)
+
+			if e.Locale != "" {
+				override = override.WithAcceptLanguage(e.Locale)
+			}
+
+			return override.Do(ctx)
+		}))
+	}
+
+	d.log.Info("Emulating", "timezone", e.Timezone, "locale", e.Locale, "color_scheme", e.ColorScheme,
+		"width", e.Width, "height", e.Height, "device_scale_factor", e.DeviceScaleFactor, "mobile", e.Mobile, "user_agent", e.UserAgent)
+
+	return chromedp.Run(ctx, actions...)
+}
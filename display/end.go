@@ -0,0 +1,230 @@
+package display
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// endBinding is the function the page calls to end the recording.
+const endBinding = "__recorderEnd"
+
+type EndKind string
+
+const (
+	EndPageEvent  EndKind = "page_event"
+	EndConsole    EndKind = "console"
+	EndExpression EndKind = "expression"
+	EndMediaEnded EndKind = "media_ended"
+	EndURL        EndKind = "url"
+)
+
+// EndCondition lets the page end its own recording, the first condition met wins.
+type EndCondition struct {
+	// Event is the name of an event dispatched on window, e.g. "recording:done".
+	Event string
+	// Console is a regular expression matched against console messages.
+	Console string
+	// Expression is a JavaScript expression, polled every second until it is truthy.
+	Expression string
+	// MediaSelector is a CSS selector of audio or video elements whose ended event ends the recording.
+	MediaSelector string
+	// URL is a regular expression matched against the URL the page navigates to.
+	URL string
+}
+
+// Enabled reports whether there is anything to watch for.
+func (c EndCondition) Enabled() bool {
+	return c.Event != "" || c.Console != "" || c.Expression != "" || c.MediaSelector != "" || c.URL != ""
+}
+
+// Validate checks the patterns of the condition compile.
+func (c EndCondition) Validate() error {
+	if _, err := compilePattern(c.Console); err != nil {
+		return fmt.Errorf("invalid console pattern: %w", err)
+	}
+
+	if _, err := compilePattern(c.URL); err != nil {
+		return fmt.Errorf("invalid url pattern: %w", err)
+	}
+
+	return nil
+}
+
+// WatchEnd watches the page for the condition, calling onEnd once when it's met.
+// onEnd is called on its own goroutine, so it may close the Display.
+func (d *Display) WatchEnd(c EndCondition, onEnd func(kind EndKind, detail string)) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if d.browser == nil {
+		return fmt.Errorf("chrome is not running")
+	}
+
+	console, err := compilePattern(c.Console)
+
+	if err != nil {
+		return fmt.Errorf("invalid console pattern: %w", err)
+	}
+
+	url, err := compilePattern(c.URL)
+
+	if err != nil {
+		return fmt.Errorf("invalid url pattern: %w", err)
+	}
+
+	ctx := d.browser.chromeCtx
+	once := sync.Once{}
+
+	end := func(kind EndKind, detail string) {
+		once.Do(func() {
+			d.log.Info("End condition met", "kind", kind, "detail", detail)
+			go onEnd(kind, detail)
+		})
+	}
+
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *runtime.EventBindingCalled:
+			if ev.Name != endBinding {
+				break
+			}
+
+			if kind, detail, ok := bindingEnd(c, ev.Payload); ok {
+				end(kind, detail)
+			} else {
+				d.log.Debug("Ignoring unexpected end binding call", "payload", ev.Payload)
+			}
+		case *runtime.EventConsoleAPICalled:
+			if text := consoleText(ev.Args); console != nil && console.MatchString(text) {
+				end(EndConsole, text)
+			}
+		case *page.EventFrameNavigated:
+			if ev.Frame.ParentID == "" && url != nil && url.MatchString(ev.Frame.URL) {
+				end(EndURL, ev.Frame.URL)
+			}
+		case *page.EventNavigatedWithinDocument:
+			if url != nil && url.MatchString(ev.URL) {
+				end(EndURL, ev.URL)
+			}
+		}
+	})
+
+	if c.Event != "" || c.MediaSelector != "" {
+		script, err := endScript(c)
+
+		if err != nil {
+			return err
+		}
+
+		// The binding and the script survive reloads and navigations, the evaluate covers the current document.
+		err = chromedp.Run(ctx,
+			runtime.AddBinding(endBinding),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				_, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+				return err
+			}),
+			chromedp.Evaluate(script, nil),
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to install end listeners: %w", err)
+		}
+	}
+
+	if c.Expression != "" {
+		d.Wg.Add(1)
+		go func() {
+			defer d.Wg.Done()
+			d.pollEnd(ctx, c.Expression, end)
+		}()
+	}
+
+	d.log.Info("Watching for the end of the page", "event", c.Event, "console", c.Console, "expression", c.Expression, "media_selector", c.MediaSelector, "url", c.URL)
+
+	return nil
+}
+
+// pollEnd evaluates the expression every second until it's truthy or the Chrome context is done.
+func (d *Display) pollEnd(ctx context.Context, expression string, end func(kind EndKind, detail string)) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var met bool
+
+		if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf("Boolean(%s)", expression), &met)); err != nil {
+			d.log.Debug("Failed to evaluate end expression", "error", err)
+			continue
+		}
+
+		if met {
+			end(EndExpression, expression)
+			return
+		}
+	}
+}
+
+// bindingEnd parses a call of the end binding. The binding is callable by any script on the page, so a call can't be told
+// apart from one of our own script: the filter only guarantees the recording ends through a kind the condition sets,
+// reported with the configured event or selector rather than anything the page passed.
+func bindingEnd(c EndCondition, payload string) (EndKind, string, bool) {
+	switch kind := EndKind(payload); {
+	case kind == EndPageEvent && c.Event != "":
+		return kind, c.Event, true
+	case kind == EndMediaEnded && c.MediaSelector != "":
+		return kind, c.MediaSelector, true
+	}
+
+	return "", "", false
+}
+
+// endScript returns the script listening for the window event and the media ended events.
+func endScript(c EndCondition) (string, error) {
+	event, err := json.Marshal(c.Event)
+
+	if err != nil {
+		return "", err
+	}
+
+	selector, err := json.Marshal(c.MediaSelector)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`(() => {
+	if (window.__recorderEndInstalled) return;
+	window.__recorderEndInstalled = true;
+	const end = (kind) => window.%[1]s && window.%[1]s(kind);
+	const event = %[2]s;
+	const selector = %[3]s;
+	if (event) window.addEventListener(event, () => end(%[4]q));
+	if (selector) document.addEventListener("ended", (e) => {
+		if (e.target instanceof Element && e.target.matches(selector)) end(%[5]q);
+	}, true);
+})()`, endBinding, event, selector, EndPageEvent, EndMediaEnded), nil
+}
+
+// compilePattern compiles a regular expression, an empty pattern is nil.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile(pattern)
+}
@@ -0,0 +1,61 @@
+package display_test
+
+import (
+	"testing"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndConditionValidate(t *testing.T) {
+	assert.False(t, display.EndCondition{}.Enabled())
+	assert.True(t, display.EndCondition{URL: "/thanks$"}.Enabled())
+
+	assert.NoError(t, display.EndCondition{Console: "^recording done", URL: "/thanks$"}.Validate())
+	assert.Error(t, display.EndCondition{Console: "("}.Validate())
+	assert.Error(t, display.EndCondition{URL: "[a-"}.Validate())
+}
+
+func TestBindingEnd(t *testing.T) {
+	both := display.EndCondition{Event: "recording:done", MediaSelector: "video#main"}
+
+	cases := []struct {
+		name      string
+		condition display.EndCondition
+		payload   string
+		kind      display.EndKind
+		detail    string
+		ok        bool
+	}{
+		{name: "page event", condition: both, payload: "page_event", kind: display.EndPageEvent, detail: "recording:done", ok: true},
+		{name: "media ended", condition: both, payload: "media_ended", kind: display.EndMediaEnded, detail: "video#main", ok: true},
+		// A kind the condition doesn't set can't end the recording, whoever calls the binding.
+		{name: "page event not watched", condition: display.EndCondition{MediaSelector: "video#main"}, payload: "page_event"},
+		{name: "media ended not watched", condition: display.EndCondition{Event: "recording:done"}, payload: "media_ended"},
+		// Kinds the binding never reports, and anything else a page script passes, are ignored.
+		{name: "console", condition: display.EndCondition{Event: "recording:done", Console: "done"}, payload: "console"},
+		{name: "url", condition: display.EndCondition{Event: "recording:done", URL: "/thanks"}, payload: "url"},
+		{name: "unknown kind", condition: both, payload: "stop"},
+		{name: "empty", condition: both, payload: ""},
+		{name: "different case", condition: both, payload: "PAGE_EVENT"},
+		{name: "json", condition: both, payload: `{"kind":"page_event"}`},
+	}
+
+	for _, c := range cases {
+		kind, detail, ok := display.BindingEnd(c.condition, c.payload)
+
+		assert.Equal(t, c.ok, ok, c.name)
+		assert.Equal(t, c.kind, kind, c.name)
+		assert.Equal(t, c.detail, detail, c.name)
+	}
+}
+
+func TestEndScriptEscapesTheCondition(t *testing.T) {
+	script, err := display.EndScript(display.EndCondition{Event: `done"); alert("x`, MediaSelector: "video"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, script, `const event = "done\"); alert(\"x";`)
+	assert.Contains(t, script, `const selector = "video";`)
+	assert.Contains(t, script, `end("page_event")`)
+	assert.Contains(t, script, `end("media_ended")`)
+}
@@ -0,0 +1,90 @@
+package display
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+// Interceptor exposes the request rules and the challenge answers of a Display to the tests.
+type Interceptor struct {
+	i *interceptor
+}
+
+func NewInterceptor(d *Display, pageUrl string) (*Interceptor, error) {
+	i, err := newInterceptor(context.Background(), d, pageUrl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Interceptor{i: i}, nil
+}
+
+func (i *Interceptor) Allowed(u string) bool {
+	return i.i.allowed(u)
+}
+
+func (i *Interceptor) Headers(r *network.Request) []*fetch.HeaderEntry {
+	return i.i.headers(r)
+}
+
+func (i *Interceptor) Answer(c *fetch.AuthChallenge) *fetch.AuthChallengeResponse {
+	return i.i.answer(c)
+}
+
+// RecordHAR feeds network events to a HAR recorder redacting secretHeaders, returning its archive.
+func RecordHAR(secretHeaders map[string]string, events ...any) *HAR {
+	h := newHARRecorder(secretHeaders)
+
+	for _, ev := range events {
+		h.listen(ev)
+	}
+
+	return h.archive()
+}
+
+var (
+	Hung            = hung
+	Backoff         = backoff
+	SettledAttempt  = settledAttempt
+	RecoveryActions = recoveryActions
+)
+
+// FrameWatch exposes the freeze detection of the watchdog to the tests.
+type FrameWatch struct {
+	f frameWatch
+}
+
+func (f *FrameWatch) Still(frame []byte, now time.Time, after time.Duration) bool {
+	return f.f.still(frame, now, after)
+}
+
+// WaitReadyWithin runs the ready wait of d against ctx, without a browser only Delay can be waited for.
+func (d *Display) WaitReadyWithin(ctx context.Context, c ReadyCondition) error {
+	return d.waitReadyWithin(ctx, c)
+}
+
+// RequestTracker exposes the network idle wait to the tests.
+type RequestTracker struct {
+	t *requestTracker
+}
+
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{t: newRequestTracker()}
+}
+
+func (t *RequestTracker) Listen(ev any) {
+	t.t.listen(ev)
+}
+
+func (t *RequestTracker) Wait(ctx context.Context, idle time.Duration) error {
+	return t.t.wait(ctx, idle)
+}
+
+var (
+	BindingEnd = bindingEnd
+	EndScript  = endScript
+)
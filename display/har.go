@@ -0,0 +1,357 @@
+package display
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// MaxHAREntries caps the requests kept in the HAR of a recording, later requests are counted but dropped.
+const MaxHAREntries = 10000
+
+// sensitiveHeaders are the headers whose values are redacted from the HAR.
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// HAR is an HTTP Archive 1.2 log, see http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+	Comment string      `json:"comment,omitempty"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+
+	// start is the monotonic time the request was sent at, in seconds.
+	start float64
+}
+
+type HARRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []HARCookie `json:"cookies"`
+	Headers     []HARHeader `json:"headers"`
+	QueryString []HARHeader `json:"queryString"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int64       `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []HARCookie `json:"cookies"`
+	Headers     []HARHeader `json:"headers"`
+	Content     HARContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type HARCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// HARTimings are in milliseconds, -1 when they don't apply.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harRecorder builds the HAR of a page from its network events, it's registered with chromedp.ListenTarget.
+type harRecorder struct {
+	mu       sync.Mutex
+	entries  []*HAREntry
+	inflight map[network.RequestID]*HAREntry
+	dropped  int
+
+	// redact holds the lower case names of the headers whose values are secrets.
+	redact map[string]bool
+}
+
+func newHARRecorder(secretHeaders map[string]string) *harRecorder {
+	redact := make(map[string]bool, len(sensitiveHeaders)+len(secretHeaders))
+
+	for name := range sensitiveHeaders {
+		redact[name] = true
+	}
+
+	for name := range secretHeaders {
+		redact[strings.ToLower(name)] = true
+	}
+
+	return &harRecorder{
+		inflight: make(map[network.RequestID]*HAREntry),
+		redact:   redact,
+	}
+}
+
+func (h *harRecorder) listen(ev any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// A redirect reuses the request ID, the previous hop ends with the redirect response.
+		if e, ok := h.inflight[ev.RequestID]; ok && ev.RedirectResponse != nil {
+			h.respond(e, ev.RedirectResponse)
+			e.Response.RedirectURL = ev.Request.URL
+			h.finish(ev.RequestID, ev.Timestamp, 0, "")
+		}
+
+		h.send(ev)
+	case *network.EventResponseReceived:
+		if e, ok := h.inflight[ev.RequestID]; ok {
+			h.respond(e, ev.Response)
+		}
+	case *network.EventLoadingFinished:
+		h.finish(ev.RequestID, ev.Timestamp, ev.EncodedDataLength, "")
+	case *network.EventLoadingFailed:
+		reason := ev.ErrorText
+
+		if ev.BlockedReason != "" {
+			reason = string(ev.BlockedReason)
+		}
+
+		h.finish(ev.RequestID, ev.Timestamp, 0, reason)
+	}
+}
+
+// send starts the entry of a request.
+func (h *harRecorder) send(ev *network.EventRequestWillBeSent) {
+	if len(h.entries)+len(h.inflight) >= MaxHAREntries {
+		h.dropped++
+		return
+	}
+
+	e := &HAREntry{
+		Request: HARRequest{
+			Method:      ev.Request.Method,
+			URL:         ev.Request.URL + ev.Request.URLFragment,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARCookie{},
+			Headers:     h.headers(ev.Request.Headers),
+			QueryString: queryString(ev.Request.URL),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: HARResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARCookie{},
+			Headers:     []HARHeader{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings:      HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+		ResourceType: ev.Type.String(),
+	}
+
+	if ev.WallTime != nil {
+		e.StartedDateTime = ev.WallTime.Time().UTC()
+	}
+
+	if ev.Timestamp != nil {
+		e.start = monotonicSeconds(ev.Timestamp)
+	}
+
+	h.inflight[ev.RequestID] = e
+}
+
+// respond fills the response of an entry in, along with its timings.
+func (h *harRecorder) respond(e *HAREntry, r *network.Response) {
+	e.Response.Status = r.Status
+	e.Response.StatusText = r.StatusText
+	e.Response.Headers = h.headers(r.Headers)
+	e.Response.Content.MimeType = r.MimeType
+	e.ServerIPAddress = r.RemoteIPAddress
+
+	if r.Protocol != "" {
+		e.Request.HTTPVersion = strings.ToUpper(r.Protocol)
+		e.Response.HTTPVersion = e.Request.HTTPVersion
+	}
+
+	// The headers actually sent carry the cookies and the credentials added by the browser.
+	if len(r.RequestHeaders) > 0 {
+		e.Request.Headers = h.headers(r.RequestHeaders)
+	}
+
+	if t := r.Timing; t != nil {
+		e.Timings.DNS = span(t.DNSStart, t.DNSEnd)
+		e.Timings.Connect = span(t.ConnectStart, t.ConnectEnd)
+		e.Timings.SSL = span(t.SslStart, t.SslEnd)
+		e.Timings.Send = math.Max(span(t.SendStart, t.SendEnd), 0)
+		e.Timings.Wait = math.Max(span(t.SendEnd, t.ReceiveHeadersEnd), 0)
+	}
+}
+
+// finish moves the entry of a request to the log, once it has loaded, failed or been redirected.
+func (h *harRecorder) finish(id network.RequestID, at *cdp.MonotonicTime, size float64, reason string) {
+	e, ok := h.inflight[id]
+
+	if !ok {
+		return
+	}
+
+	delete(h.inflight, id)
+
+	if at != nil && e.start > 0 {
+		e.Time = math.Max((monotonicSeconds(at)-e.start)*1000, 0)
+	}
+
+	e.Timings.Receive = math.Max(e.Time-e.Timings.Send-e.Timings.Wait-math.Max(e.Timings.DNS, 0)-math.Max(e.Timings.Connect, 0), 0)
+	e.Response.BodySize = int64(size)
+	e.Response.Content.Size = int64(size)
+	e.Error = reason
+
+	h.entries = append(h.entries, e)
+}
+
+// headers converts the headers, sorted by name and with the secret values redacted.
+func (h *harRecorder) headers(headers network.Headers) []HARHeader {
+	converted := make([]HARHeader, 0, len(headers))
+
+	for name, value := range headers {
+		v, _ := value.(string)
+
+		if h.redact[strings.ToLower(name)] {
+			v = "[redacted]"
+		}
+
+		// Chrome joins repeated headers with new lines.
+		for _, line := range strings.Split(v, "\n") {
+			converted = append(converted, HARHeader{Name: name, Value: line})
+		}
+	}
+
+	sort.SliceStable(converted, func(i, j int) bool {
+		return converted[i].Name < converted[j].Name
+	})
+
+	return converted
+}
+
+// HAR returns the HTTP Archive of the page, nil when it isn't recorded.
+func (d *Display) HAR() *HAR {
+	if d == nil || d.har == nil {
+		return nil
+	}
+
+	return d.har.archive()
+}
+
+// archive returns the HTTP Archive, requests still in flight are included without a response.
+func (h *harRecorder) archive() *HAR {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make([]*HAREntry, 0, len(h.entries)+len(h.inflight))
+	entries = append(entries, h.entries...)
+
+	for _, e := range h.inflight {
+		pending := *e
+		pending.Error = "pending"
+		entries = append(entries, &pending)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	har := &HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "recorder", Version: "1.0"},
+			Entries: entries,
+		},
+	}
+
+	if h.dropped > 0 {
+		har.Log.Comment = fmt.Sprintf("%d requests beyond the first %d were dropped", h.dropped, MaxHAREntries)
+	}
+
+	return har
+}
+
+// queryString returns the query parameters of a URL.
+func queryString(u string) []HARHeader {
+	params := []HARHeader{}
+
+	parsed, err := url.Parse(u)
+
+	if err != nil {
+		return params
+	}
+
+	for name, values := range parsed.Query() {
+		for _, value := range values {
+			params = append(params, HARHeader{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+
+	return params
+}
+
+// span returns the milliseconds between two resource timing marks, -1 when the phase didn't happen.
+func span(start float64, end float64) float64 {
+	if start < 0 || end < 0 {
+		return -1
+	}
+
+	return end - start
+}
+
+// monotonicSeconds returns a monotonic timestamp in seconds.
+func monotonicSeconds(t *cdp.MonotonicTime) float64 {
+	return float64(t.Time().UnixNano()) / float64(time.Second)
+}
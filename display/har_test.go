@@ -0,0 +1,61 @@
+package display_test
+
+import (
+	"testing"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHARRedactsSecretHeaders(t *testing.T) {
+	har := display.RecordHAR(map[string]string{"X-Api-Key": "secret-key"},
+		&network.EventRequestWillBeSent{
+			RequestID: "1",
+			Type:      network.ResourceTypeDocument,
+			Request: &network.Request{
+				Method:  "GET",
+				URL:     "https://app.example.com/meeting/1",
+				Headers: network.Headers{"Accept": "text/html", "x-api-key": "secret-key"},
+			},
+		},
+		&network.EventResponseReceived{
+			RequestID: "1",
+			Response: &network.Response{
+				Status:   200,
+				Protocol: "h2",
+				Headers:  network.Headers{"Content-Type": "text/html", "Set-Cookie": "session=secret-session\ntheme=secret-theme"},
+				RequestHeaders: network.Headers{
+					"Accept":              "text/html",
+					"Authorization":       "Bearer secret-token",
+					"Cookie":              "session=secret-session",
+					"Proxy-Authorization": "Basic secret-proxy",
+					"X-API-KEY":           "secret-key",
+				},
+			},
+		},
+		&network.EventLoadingFinished{RequestID: "1", EncodedDataLength: 512},
+	)
+
+	if !assert.Len(t, har.Log.Entries, 1) {
+		return
+	}
+
+	e := har.Log.Entries[0]
+
+	// The headers actually sent replace the ones requested, secret values are redacted whatever their case.
+	assert.Equal(t, []display.HARHeader{
+		{Name: "Accept", Value: "text/html"},
+		{Name: "Authorization", Value: "[redacted]"},
+		{Name: "Cookie", Value: "[redacted]"},
+		{Name: "Proxy-Authorization", Value: "[redacted]"},
+		{Name: "X-API-KEY", Value: "[redacted]"},
+	}, e.Request.Headers)
+
+	assert.Equal(t, []display.HARHeader{
+		{Name: "Content-Type", Value: "text/html"},
+		{Name: "Set-Cookie", Value: "[redacted]"},
+	}, e.Response.Headers)
+
+	assert.Equal(t, int64(512), e.Response.BodySize)
+}
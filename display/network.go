@@ -0,0 +1,341 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Proxy routes the traffic of the browser through an HTTP or SOCKS proxy.
+type Proxy struct {
+	// Server is the proxy URL, e.g. "http://proxy.internal:3128" or "socks5://proxy.internal:1080".
+	Server string
+	// Bypass lists the hosts reached directly, e.g. "*.internal" or "localhost".
+	Bypass []string
+
+	// Username and Password answer the authentication challenges of an HTTP proxy, Chrome can't authenticate to SOCKS proxies.
+	Username string
+	Password string
+}
+
+// Enabled reports whether a proxy is set.
+func (p Proxy) Enabled() bool {
+	return p.Server != ""
+}
+
+// Validate checks the proxy URL and that its credentials can be used.
+func (p Proxy) Validate() error {
+	if !p.Enabled() {
+		if p.Username != "" || len(p.Bypass) > 0 {
+			return fmt.Errorf("proxy needs a server")
+		}
+
+		return nil
+	}
+
+	u, err := url.Parse(p.Server)
+
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy server %q", p.Server)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	case "socks4", "socks5":
+		if p.Username != "" {
+			return fmt.Errorf("chrome can't authenticate to a %s proxy", u.Scheme)
+		}
+	default:
+		return fmt.Errorf("proxy server must be an http, https, socks4 or socks5 URL")
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("proxy credentials go in the username and password, not the server URL")
+	}
+
+	for _, host := range p.Bypass {
+		if strings.TrimSpace(host) == "" || strings.ContainsAny(host, ", ;") {
+			return fmt.Errorf("invalid proxy bypass entry %q", host)
+		}
+	}
+
+	return nil
+}
+
+// NetworkRules decide which requests the page may make. Patterns match the whole URL, '*' matches any run of characters.
+type NetworkRules struct {
+	// Block lists the URLs failed, e.g. "*://*.doubleclick.net/*".
+	Block []string
+	// Allow, when set, fails every request that doesn't match one of its patterns.
+	Allow []string
+}
+
+// Enabled reports whether there is anything to enforce.
+func (r NetworkRules) Enabled() bool {
+	return len(r.Block) > 0 || len(r.Allow) > 0
+}
+
+// Validate checks every pattern compiles.
+func (r NetworkRules) Validate() error {
+	if _, err := compileURLPatterns(r.Block); err != nil {
+		return fmt.Errorf("invalid block pattern: %w", err)
+	}
+
+	if _, err := compileURLPatterns(r.Allow); err != nil {
+		return fmt.Errorf("invalid allow pattern: %w", err)
+	}
+
+	return nil
+}
+
+// BlockedRequests returns how many requests the NetworkRules failed so far, it's nil safe.
+func (d *Display) BlockedRequests() int64 {
+	if d == nil {
+		return 0
+	}
+
+	return d.blocked.Load()
+}
+
+// proxyOptions returns the Chrome flags routing the browser through the proxy.
+func (d *Display) proxyOptions() []chromedp.ExecAllocatorOption {
+	if !d.Proxy.Enabled() {
+		return nil
+	}
+
+	opts := []chromedp.ExecAllocatorOption{chromedp.ProxyServer(d.Proxy.Server)}
+
+	if len(d.Proxy.Bypass) > 0 {
+		opts = append(opts, chromedp.Flag("proxy-bypass-list", strings.Join(d.Proxy.Bypass, ";")))
+	}
+
+	d.log.Info("Using proxy", "server", d.Proxy.Server, "bypass", d.Proxy.Bypass, "authenticated", d.Proxy.Username != "")
+
+	return opts
+}
+
+// interceptor pauses the requests of the page to enforce the NetworkRules and answer authentication challenges.
+type interceptor struct {
+	d      *Display
+	ctx    context.Context
+	origin string
+
+	block []*regexp.Regexp
+	allow []*regexp.Regexp
+}
+
+// applyInterception turns request interception on when the rules, the session headers, the basic auth or the proxy credentials need it.
+// It has to run before the first navigation.
+func (d *Display) applyInterception(ctx context.Context, pageUrl string) error {
+	basicAuth := d.Session.BasicAuth != nil
+	proxyAuth := d.Proxy.Enabled() && d.Proxy.Username != ""
+	headers := len(d.Session.Headers) > 0
+
+	if !basicAuth && !proxyAuth && !headers && !d.Network.Enabled() {
+		return nil
+	}
+
+	i, err := newInterceptor(ctx, d, pageUrl)
+
+	if err != nil {
+		return err
+	}
+
+	if basicAuth {
+		d.log.Info("Answering basic auth challenges", "origin", i.origin, "username", d.Session.BasicAuth.Username)
+	}
+
+	if headers {
+		names := make([]string, 0, len(d.Session.Headers))
+
+		for name := range d.Session.Headers {
+			names = append(names, name)
+		}
+
+		d.log.Info("Sending extra HTTP headers", "origin", i.origin, "names", names)
+	}
+
+	if d.Network.Enabled() {
+		d.log.Info("Enforcing network rules", "block", d.Network.Block, "allow", d.Network.Allow)
+	}
+
+	chromedp.ListenTarget(ctx, i.listen)
+
+	return chromedp.Run(ctx, fetch.Enable().WithHandleAuthRequests(basicAuth || proxyAuth))
+}
+
+// newInterceptor compiles the NetworkRules of the Display, pageUrl gives the origin the basic auth is sent to.
+func newInterceptor(ctx context.Context, d *Display, pageUrl string) (*interceptor, error) {
+	target, err := url.Parse(pageUrl)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	i := &interceptor{
+		d:      d,
+		ctx:    ctx,
+		origin: target.Scheme + "://" + target.Host,
+	}
+
+	if i.block, err = compileURLPatterns(d.Network.Block); err != nil {
+		return nil, fmt.Errorf("invalid block pattern: %w", err)
+	}
+
+	if i.allow, err = compileURLPatterns(d.Network.Allow); err != nil {
+		return nil, fmt.Errorf("invalid allow pattern: %w", err)
+	}
+
+	return i, nil
+}
+
+// listen answers the paused requests and the authentication challenges.
+// Listeners can't block on the target, so the answers are sent from their own goroutines.
+func (i *interceptor) listen(ev any) {
+	switch ev := ev.(type) {
+	case *fetch.EventRequestPaused:
+		if ev.Request != nil && !i.allowed(ev.Request.URL) {
+			i.d.blocked.Add(1)
+			i.d.log.Debug("Blocked request", "url", ev.Request.URL)
+			go i.run(fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient))
+			return
+		}
+
+		go i.run(fetch.ContinueRequest(ev.RequestID))
+	case *fetch.EventAuthRequired:
+		go i.run(fetch.ContinueWithAuth(ev.RequestID, i.answer(ev.AuthChallenge)))
+	}
+}
+
+// allowed reports whether the rules let the request through.
+func (i *interceptor) allowed(u string) bool {
+	for _, re := range i.block {
+		if re.MatchString(u) {
+			return false
+		}
+	}
+
+	if len(i.allow) == 0 {
+		return true
+	}
+
+	for _, re := range i.allow {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// headers returns the request headers merged with the session headers when the request goes to the origin of the recorded URL,
+// nil leaves the request untouched so the headers don't leak to third parties.
+func (i *interceptor) headers(r *network.Request) []*fetch.HeaderEntry {
+	if r == nil || len(i.d.Session.Headers) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(r.URL)
+
+	if err != nil || u.Scheme+"://"+u.Host != i.origin {
+		return nil
+	}
+
+	headers := make([]*fetch.HeaderEntry, 0, len(r.Headers)+len(i.d.Session.Headers))
+
+	for name, value := range r.Headers {
+		if hasHeader(i.d.Session.Headers, name) {
+			continue
+		}
+
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+	}
+
+	for name, value := range i.d.Session.Headers {
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: value})
+	}
+
+	return headers
+}
+
+// hasHeader reports whether headers has name, header names are case insensitive.
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// answer returns the credentials for a challenge. Only the proxy and the origin of the recorded URL get them,
+// every other challenge gets the default answer so the credentials don't leak to third parties.
+func (i *interceptor) answer(c *fetch.AuthChallenge) *fetch.AuthChallengeResponse {
+	if c != nil {
+		if c.Source == fetch.AuthChallengeSourceProxy && i.d.Proxy.Username != "" {
+			return &fetch.AuthChallengeResponse{
+				Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+				Username: i.d.Proxy.Username,
+				Password: i.d.Proxy.Password,
+			}
+		}
+
+		if auth := i.d.Session.BasicAuth; c.Source != fetch.AuthChallengeSourceProxy && auth != nil && c.Origin == i.origin {
+			return &fetch.AuthChallengeResponse{
+				Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+				Username: auth.Username,
+				Password: auth.Password,
+			}
+		}
+	}
+
+	return &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+}
+
+// run sends a fetch command to the target.
+func (i *interceptor) run(action chromedp.Action) {
+	c := chromedp.FromContext(i.ctx)
+
+	if c == nil || c.Target == nil {
+		return
+	}
+
+	if err := action.Do(cdp.WithExecutor(i.ctx, c.Target)); err != nil && i.ctx.Err() == nil {
+		i.d.log.Debug("Failed to answer paused request", "error", err)
+	}
+}
+
+// compileURLPatterns compiles wildcard URL patterns into anchored regular expressions.
+func compileURLPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("empty pattern")
+		}
+
+		parts := strings.Split(p, "*")
+
+		for j, part := range parts {
+			parts[j] = regexp.QuoteMeta(part)
+		}
+
+		re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", p, err)
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
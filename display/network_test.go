@@ -0,0 +1,112 @@
+package display_test
+
+import (
+	"testing"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func newInterceptor(t *testing.T, opts display.DisplayOptions) *display.Interceptor {
+	i, err := display.NewInterceptor(display.NewDisplay(opts), "https://app.example.com/meeting/1")
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return i
+}
+
+func TestAnswerSendsCredentialsOnlyWhereTheyBelong(t *testing.T) {
+	i := newInterceptor(t, display.DisplayOptions{
+		Session: display.Session{BasicAuth: &display.BasicAuth{Username: "site", Password: "site-pass"}},
+		Proxy:   display.Proxy{Server: "http://proxy.internal:3128", Username: "proxy", Password: "proxy-pass"},
+	})
+
+	provide := fetch.AuthChallengeResponseResponseProvideCredentials
+	fallback := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+
+	// The recorded origin gets the basic auth.
+	assert.Equal(t, &fetch.AuthChallengeResponse{Response: provide, Username: "site", Password: "site-pass"},
+		i.Answer(&fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "https://app.example.com"}))
+
+	// The proxy gets its own credentials, whatever the origin.
+	assert.Equal(t, &fetch.AuthChallengeResponse{Response: provide, Username: "proxy", Password: "proxy-pass"},
+		i.Answer(&fetch.AuthChallenge{Source: fetch.AuthChallengeSourceProxy, Origin: "http://proxy.internal:3128"}))
+
+	// Nothing for third parties, another scheme or port of the host included.
+	assert.Equal(t, fallback, i.Answer(&fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "https://cdn.example.com"}))
+	assert.Equal(t, fallback, i.Answer(&fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "http://app.example.com"}))
+	assert.Equal(t, fallback, i.Answer(&fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "https://app.example.com:8443"}))
+	assert.Equal(t, fallback, i.Answer(nil))
+}
+
+func TestAnswerWithoutProxyCredentials(t *testing.T) {
+	i := newInterceptor(t, display.DisplayOptions{
+		Session: display.Session{BasicAuth: &display.BasicAuth{Username: "site", Password: "site-pass"}},
+	})
+
+	// A proxy challenge never gets the basic auth of the site, even from the recorded origin.
+	assert.Equal(t, &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault},
+		i.Answer(&fetch.AuthChallenge{Source: fetch.AuthChallengeSourceProxy, Origin: "https://app.example.com"}))
+}
+
+func TestNetworkRules(t *testing.T) {
+	blocking := newInterceptor(t, display.DisplayOptions{
+		Network: display.NetworkRules{Block: []string{"*://*.doubleclick.net/*", "https://app.example.com/track?*"}},
+	})
+
+	assert.False(t, blocking.Allowed("https://ad.doubleclick.net/pixel.gif"))
+	assert.False(t, blocking.Allowed("https://app.example.com/track?id=1"))
+	assert.True(t, blocking.Allowed("https://app.example.com/tracking"))
+	// Patterns match the whole URL, dots are literal.
+	assert.True(t, blocking.Allowed("https://doubleclick.net.example.com/"))
+	assert.True(t, blocking.Allowed("https://adXdoubleclick.net/"))
+
+	allowing := newInterceptor(t, display.DisplayOptions{
+		Network: display.NetworkRules{
+			Allow: []string{"https://app.example.com/*", "https://cdn.example.com/*"},
+			Block: []string{"https://cdn.example.com/ads/*"},
+		},
+	})
+
+	assert.True(t, allowing.Allowed("https://app.example.com/meeting/1"))
+	assert.True(t, allowing.Allowed("https://cdn.example.com/app.js"))
+	// Block wins over allow.
+	assert.False(t, allowing.Allowed("https://cdn.example.com/ads/banner.js"))
+	assert.False(t, allowing.Allowed("https://evil.example.org/app.example.com/"))
+
+	_, err := display.NewInterceptor(display.NewDisplay(display.DisplayOptions{Network: display.NetworkRules{Block: []string{" "}}}), "https://app.example.com")
+	assert.Error(t, err)
+}
+
+func TestHeadersOnlyGoToTheRecordedOrigin(t *testing.T) {
+	i := newInterceptor(t, display.DisplayOptions{
+		Session: display.Session{Headers: map[string]string{"Authorization": "Bearer s3cr3t"}},
+	})
+
+	headers := i.Headers(&network.Request{
+		URL:     "https://app.example.com/api/me",
+		Headers: network.Headers{"Accept": "application/json", "authorization": "Bearer page"},
+	})
+
+	// The session header replaces the one of the page, whatever its case.
+	assert.ElementsMatch(t, []*fetch.HeaderEntry{
+		{Name: "Accept", Value: "application/json"},
+		{Name: "Authorization", Value: "Bearer s3cr3t"},
+	}, headers)
+
+	// Third parties, another scheme or port of the host included, get the request untouched.
+	assert.Nil(t, i.Headers(&network.Request{URL: "https://cdn.example.com/app.js"}))
+	assert.Nil(t, i.Headers(&network.Request{URL: "http://app.example.com/api/me"}))
+	assert.Nil(t, i.Headers(&network.Request{URL: "https://app.example.com:8443/api/me"}))
+	assert.Nil(t, i.Headers(nil))
+}
+
+func TestNoHeadersLeavesRequestsUntouched(t *testing.T) {
+	i := newInterceptor(t, display.DisplayOptions{})
+
+	assert.Nil(t, i.Headers(&network.Request{URL: "https://app.example.com/api/me"}))
+}
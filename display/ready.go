@@ -0,0 +1,202 @@
+package display
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultReadyTimeout bounds the wait for a ReadyCondition that sets no Timeout.
+const DefaultReadyTimeout = 30 * time.Second
+
+// ErrNotReady is returned when the page doesn't meet its ReadyCondition in time.
+var ErrNotReady = errors.New("page did not become ready in time")
+
+// ReadyCondition is what the page has to meet before it's recorded. Every condition set must hold, in the order below.
+type ReadyCondition struct {
+	// Selector is a CSS selector that has to be visible.
+	Selector string
+	// Expression is a JavaScript expression that has to become truthy.
+	Expression string
+	// NetworkIdle is how long the page must go without a request in flight.
+	NetworkIdle time.Duration
+	// Delay is a fixed wait, after the conditions above.
+	Delay time.Duration
+
+	// Timeout bounds the whole wait, it defaults to DefaultReadyTimeout.
+	Timeout time.Duration
+	// Proceed records anyway when the condition times out, instead of failing.
+	Proceed bool
+}
+
+// Enabled reports whether there is anything to wait for.
+func (c ReadyCondition) Enabled() bool {
+	return c.Selector != "" || c.Expression != "" || c.NetworkIdle > 0 || c.Delay > 0
+}
+
+// WaitReady waits until the page meets the condition, returning ErrNotReady when it times out.
+func (d *Display) WaitReady(c ReadyCondition) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if d.browser == nil {
+		return fmt.Errorf("chrome is not running")
+	}
+
+	return d.waitReadyWithin(d.browser.chromeCtx, c)
+}
+
+// waitReadyWithin bounds the wait for the condition with its timeout, telling a timeout apart from a failed wait.
+func (d *Display) waitReadyWithin(ctx context.Context, c ReadyCondition) error {
+	timeout := c.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	d.log.Info("Waiting for the page to be ready", "selector", c.Selector, "expression", c.Expression, "network_idle", c.NetworkIdle, "delay", c.Delay, "timeout", timeout)
+
+	err := d.waitReady(ctx, c)
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%w after %s: %v", ErrNotReady, timeout, err)
+		}
+
+		return err
+	}
+
+	d.log.Info("Page is ready", "waited", time.Since(start))
+
+	return nil
+}
+
+func (d *Display) waitReady(ctx context.Context, c ReadyCondition) error {
+	if c.Selector != "" {
+		if err := chromedp.Run(ctx, chromedp.WaitVisible(c.Selector, chromedp.ByQuery)); err != nil {
+			return fmt.Errorf("waiting for selector %q: %w", c.Selector, err)
+		}
+	}
+
+	if c.Expression != "" {
+		var res any
+
+		err := chromedp.Run(ctx, chromedp.Poll(c.Expression, &res,
+			chromedp.WithPollingInterval(250*time.Millisecond),
+			chromedp.WithPollingTimeout(0),
+		))
+
+		if err != nil {
+			return fmt.Errorf("waiting for expression: %w", err)
+		}
+	}
+
+	if c.NetworkIdle > 0 {
+		if err := waitNetworkIdle(ctx, c.NetworkIdle); err != nil {
+			return fmt.Errorf("waiting for network idle: %w", err)
+		}
+	}
+
+	if c.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.Delay):
+		}
+	}
+
+	return nil
+}
+
+// waitNetworkIdle waits until no request has been in flight for idle.
+// Requests sent before the wait started aren't known, so only the ones sent since are tracked.
+func waitNetworkIdle(ctx context.Context, idle time.Duration) error {
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	t := newRequestTracker()
+	chromedp.ListenTarget(listenCtx, t.listen)
+
+	return t.wait(ctx, idle)
+}
+
+// requestTracker keeps the requests of the page in flight.
+type requestTracker struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]struct{}
+	changed  chan struct{}
+}
+
+func newRequestTracker() *requestTracker {
+	return &requestTracker{
+		inflight: make(map[network.RequestID]struct{}),
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// listen tracks the network events, it's registered with chromedp.ListenTarget.
+func (t *requestTracker) listen(ev any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		t.inflight[ev.RequestID] = struct{}{}
+	case *network.EventLoadingFinished:
+		delete(t.inflight, ev.RequestID)
+	case *network.EventLoadingFailed:
+		delete(t.inflight, ev.RequestID)
+	default:
+		return
+	}
+
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+// busy reports whether a request is in flight.
+func (t *requestTracker) busy() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.inflight) > 0
+}
+
+// wait returns once no request has been in flight for idle, or when ctx is done.
+func (t *requestTracker) wait(ctx context.Context, idle time.Duration) error {
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.changed:
+			// Any activity restarts the idle period.
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			if !t.busy() {
+				timer.Reset(idle)
+			}
+		case <-timer.C:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package display_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyConditionEnabled(t *testing.T) {
+	assert.False(t, display.ReadyCondition{}.Enabled())
+	assert.False(t, display.ReadyCondition{Timeout: time.Second, Proceed: true}.Enabled())
+	assert.True(t, display.ReadyCondition{Selector: "#video"}.Enabled())
+	assert.True(t, display.ReadyCondition{Expression: "window.ready"}.Enabled())
+	assert.True(t, display.ReadyCondition{NetworkIdle: time.Second}.Enabled())
+	assert.True(t, display.ReadyCondition{Delay: time.Second}.Enabled())
+}
+
+func TestWaitReadyWithoutBrowser(t *testing.T) {
+	d := display.NewDisplay(display.DisplayOptions{})
+
+	// Nothing to wait for.
+	assert.NoError(t, d.WaitReady(display.ReadyCondition{}))
+	assert.Error(t, d.WaitReady(display.ReadyCondition{Delay: time.Millisecond}))
+}
+
+func TestWaitReadyTimesOut(t *testing.T) {
+	d := display.NewDisplay(display.DisplayOptions{})
+
+	assert.NoError(t, d.WaitReadyWithin(context.Background(), display.ReadyCondition{Delay: 10 * time.Millisecond, Timeout: time.Second}))
+
+	err := d.WaitReadyWithin(context.Background(), display.ReadyCondition{Delay: time.Minute, Timeout: 20 * time.Millisecond})
+	assert.ErrorIs(t, err, display.ErrNotReady)
+
+	// The recording going away isn't a timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = d.WaitReadyWithin(ctx, display.ReadyCondition{Delay: time.Minute, Timeout: time.Minute})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, errors.Is(err, display.ErrNotReady))
+}
+
+func TestNetworkIdle(t *testing.T) {
+	// A page that sends nothing is idle once the idle period is over.
+	assert.NoError(t, display.NewRequestTracker().Wait(context.Background(), 10*time.Millisecond))
+
+	tracker := display.NewRequestTracker()
+	tracker.Listen(&network.EventRequestWillBeSent{RequestID: "1"})
+	tracker.Listen(&network.EventRequestWillBeSent{RequestID: "2"})
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.Wait(context.Background(), 50*time.Millisecond) }()
+
+	// Requests in flight hold the wait back.
+	time.Sleep(100 * time.Millisecond)
+	tracker.Listen(&network.EventLoadingFinished{RequestID: "1"})
+
+	select {
+	case err := <-done:
+		t.Fatalf("network idle with a request in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The idle period starts over once the last request is done.
+	tracker.Listen(&network.EventLoadingFailed{RequestID: "2"})
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("network never idle")
+	}
+}
+
+func TestNetworkIdleTimesOut(t *testing.T) {
+	tracker := display.NewRequestTracker()
+	tracker.Listen(&network.EventRequestWillBeSent{RequestID: "1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, tracker.Wait(ctx, 10*time.Millisecond), context.DeadlineExceeded)
+}
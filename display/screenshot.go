@@ -0,0 +1,170 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+type ScreenshotSource string
+
+const (
+	// SourceCDP captures the page through Chrome, only what the page renders.
+	SourceCDP ScreenshotSource = "cdp"
+	// SourceX11 captures the whole X display, overlays and other windows included.
+	SourceX11 ScreenshotSource = "x11"
+)
+
+type ScreenshotFormat string
+
+const (
+	FormatPNG  ScreenshotFormat = "png"
+	FormatJPEG ScreenshotFormat = "jpeg"
+)
+
+// ScreenshotOptions shape a capture, zero values are a full size PNG of the page.
+type ScreenshotOptions struct {
+	Source ScreenshotSource
+	Format ScreenshotFormat
+	// Quality is the JPEG quality, from 1 to 100.
+	Quality int
+	// Scale shrinks the capture, from 0 (full size) to 1.
+	Scale float64
+}
+
+// Validate checks the options, filling the defaults in.
+func (o *ScreenshotOptions) Validate() error {
+	switch o.Source {
+	case "":
+		o.Source = SourceCDP
+	case SourceCDP, SourceX11:
+	default:
+		return fmt.Errorf("source must be cdp or x11")
+	}
+
+	switch o.Format {
+	case "":
+		o.Format = FormatPNG
+	case FormatPNG, FormatJPEG:
+	default:
+		return fmt.Errorf("format must be png or jpeg")
+	}
+
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+
+	if o.Quality == 0 {
+		o.Quality = 80
+	}
+
+	if o.Scale < 0 || o.Scale > 1 {
+		return fmt.Errorf("scale must be between 0 and 1")
+	}
+
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+
+	return nil
+}
+
+// Screenshot captures the page as a PNG.
+func (d *Display) Screenshot() ([]byte, error) {
+	return d.Capture(ScreenshotOptions{})
+}
+
+// Capture takes a screenshot of the page, or of the whole X display.
+func (d *Display) Capture(opts ScreenshotOptions) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Source == SourceX11 {
+		return d.captureX11(opts)
+	}
+
+	if d.browser == nil {
+		return nil, fmt.Errorf("chrome is not running")
+	}
+
+	ctx, cancel := context.WithTimeout(d.browser.chromeCtx, DefaultActionTimeout)
+	defer cancel()
+
+	var image []byte
+
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		capture := page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormat(opts.Format))
+
+		if opts.Format == FormatJPEG {
+			capture = capture.WithQuality(int64(opts.Quality))
+		}
+
+		if opts.Scale < 1 {
+			capture = capture.WithClip(&page.Viewport{
+				Width:  float64(d.Width),
+				Height: float64(d.Height),
+				Scale:  opts.Scale,
+			})
+		}
+
+		var err error
+		image, err = capture.Do(ctx)
+
+		return err
+	}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// captureX11 grabs a single frame of the X display with ffmpeg.
+func (d *Display) captureX11(opts ScreenshotOptions) ([]byte, error) {
+	if d.xvfb == nil {
+		return nil, fmt.Errorf("xvfb is not running")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultActionTimeout)
+	defer cancel()
+
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-f", "x11grab",
+		"-video_size", fmt.Sprintf("%dx%d", d.Width, d.Height),
+		"-i", d.DisplayId,
+		"-frames:v", "1",
+	}
+
+	if opts.Scale < 1 {
+		args = append(args, "-vf", fmt.Sprintf("scale=trunc(iw*%[1]g/2)*2:trunc(ih*%[1]g/2)*2", opts.Scale))
+	}
+
+	if opts.Format == FormatJPEG {
+		// mjpeg goes from 2, the best, to 31.
+		args = append(args, "-c:v", "mjpeg", "-q:v", strconv.Itoa(31-(opts.Quality-1)*29/99))
+	} else {
+		args = append(args, "-c:v", "png")
+	}
+
+	args = append(args, "-f", "image2pipe", "-")
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,44 @@
+package display_test
+
+import (
+	"testing"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreenshotOptionsDefaults(t *testing.T) {
+	opts := display.ScreenshotOptions{}
+
+	assert.NoError(t, opts.Validate())
+	assert.Equal(t, display.ScreenshotOptions{Source: display.SourceCDP, Format: display.FormatPNG, Quality: 80, Scale: 1}, opts)
+
+	opts = display.ScreenshotOptions{Source: display.SourceX11, Format: display.FormatJPEG, Quality: 30, Scale: 0.25}
+
+	assert.NoError(t, opts.Validate())
+	assert.Equal(t, display.ScreenshotOptions{Source: display.SourceX11, Format: display.FormatJPEG, Quality: 30, Scale: 0.25}, opts)
+}
+
+func TestScreenshotOptionsBounds(t *testing.T) {
+	cases := []struct {
+		name  string
+		opts  display.ScreenshotOptions
+		valid bool
+	}{
+		{name: "lowest quality", opts: display.ScreenshotOptions{Quality: 1}, valid: true},
+		{name: "highest quality", opts: display.ScreenshotOptions{Quality: 100}, valid: true},
+		{name: "full scale", opts: display.ScreenshotOptions{Scale: 1}, valid: true},
+		{name: "small scale", opts: display.ScreenshotOptions{Scale: 0.01}, valid: true},
+		{name: "negative quality", opts: display.ScreenshotOptions{Quality: -1}, valid: false},
+		{name: "quality too high", opts: display.ScreenshotOptions{Quality: 101}, valid: false},
+		{name: "negative scale", opts: display.ScreenshotOptions{Scale: -0.5}, valid: false},
+		{name: "scale above full size", opts: display.ScreenshotOptions{Scale: 1.5}, valid: false},
+		{name: "unknown source", opts: display.ScreenshotOptions{Source: "vnc"}, valid: false},
+		{name: "unknown format", opts: display.ScreenshotOptions{Format: "webp"}, valid: false},
+	}
+
+	for _, c := range cases {
+		err := c.opts.Validate()
+		assert.Equal(t, c.valid, err == nil, "%s: %v", c.name, err)
+	}
+}
@@ -0,0 +1,166 @@
+package display
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+type Cookie struct {
+	Name  string
+	Value string
+	// Domain defaults to the host of the recorded URL.
+	Domain   string
+	Path     string
+	Secure   bool
+	HTTPOnly bool
+	// SameSite is Strict, Lax or None.
+	SameSite string
+	// Expires is when the cookie expires, the zero value makes it a session cookie.
+	Expires time.Time
+}
+
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Session is the browser state applied before the page is navigated to, so authenticated pages can be recorded.
+type Session struct {
+	Cookies []Cookie
+	// LocalStorage and SessionStorage are set on the origin of the recorded URL before its scripts run.
+	LocalStorage   map[string]string
+	SessionStorage map[string]string
+	// Headers are sent with the requests to the origin of the recorded URL, through the request interception.
+	Headers map[string]string
+	// BasicAuth answers the HTTP authentication challenges of the origin of the recorded URL, through the request interception.
+	BasicAuth *BasicAuth
+}
+
+// Enabled reports whether there is anything to apply.
+func (s Session) Enabled() bool {
+	return len(s.Cookies) > 0 || len(s.LocalStorage) > 0 || len(s.SessionStorage) > 0 || len(s.Headers) > 0 || s.BasicAuth != nil
+}
+
+// Validate checks the cookies and the credentials.
+func (s Session) Validate() error {
+	for i, c := range s.Cookies {
+		if c.Name == "" {
+			return fmt.Errorf("cookie %d needs a name", i)
+		}
+
+		switch c.SameSite {
+		case "", string(network.CookieSameSiteStrict), string(network.CookieSameSiteLax), string(network.CookieSameSiteNone):
+		default:
+			return fmt.Errorf("cookie %d: same_site must be Strict, Lax or None", i)
+		}
+	}
+
+	for name := range s.Headers {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, ": \r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+
+	if s.BasicAuth != nil && s.BasicAuth.Username == "" {
+		return fmt.Errorf("basic auth needs a username")
+	}
+
+	return nil
+}
+
+// applySession sets the session up on the tab, it has to run before the first navigation.
+// Only names are logged, values are secrets.
+func (d *Display) applySession(ctx context.Context, pageUrl string) error {
+	s := d.Session
+
+	if !s.Enabled() {
+		return nil
+	}
+
+	target, err := url.Parse(pageUrl)
+
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	origin := target.Scheme + "://" + target.Host
+
+	actions := make([]chromedp.Action, 0, 2)
+
+	if len(s.Cookies) > 0 {
+		cookies := make([]*network.CookieParam, 0, len(s.Cookies))
+		names := make([]string, 0, len(s.Cookies))
+
+		for _, c := range s.Cookies {
+			cookie := &network.CookieParam{
+				Name:     c.Name,
+				Value:    c.Value,
+				Domain:   c.Domain,
+				Path:     c.Path,
+				Secure:   c.Secure,
+				HTTPOnly: c.HTTPOnly,
+				SameSite: network.CookieSameSite(c.SameSite),
+			}
+
+			if c.Domain == "" {
+				cookie.URL = pageUrl
+			}
+
+			if !c.Expires.IsZero() {
+				expires := cdp.TimeSinceEpoch(c.Expires)
+				cookie.Expires = &expires
+			}
+
+			cookies = append(cookies, cookie)
+			names = append(names, c.Name)
+		}
+
+		actions = append(actions, network.SetCookies(cookies))
+		d.log.Info("Setting cookies", "names", names)
+	}
+
+	if len(s.LocalStorage) > 0 || len(s.SessionStorage) > 0 {
+		script, err := storageScript(origin, s.LocalStorage, s.SessionStorage)
+
+		if err != nil {
+			return err
+		}
+
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+			return err
+		}))
+		d.log.Info("Setting web storage", "origin", origin, "local_storage", len(s.LocalStorage), "session_storage", len(s.SessionStorage))
+	}
+
+	return chromedp.Run(ctx, actions...)
+}
+
+// storageScript returns the script filling localStorage and sessionStorage on documents of origin.
+func storageScript(origin string, local map[string]string, session map[string]string) (string, error) {
+	payload, err := json.Marshal(map[string]any{
+		"origin":         origin,
+		"localStorage":   local,
+		"sessionStorage": session,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`(() => {
+	const s = %s;
+	if (location.origin !== s.origin) return;
+	for (const [k, v] of Object.entries(s.localStorage || {})) localStorage.setItem(k, v);
+	for (const [k, v] of Object.entries(s.sessionStorage || {})) sessionStorage.setItem(k, v);
+})()`, payload), nil
+}
@@ -0,0 +1,320 @@
+package display
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// missedHeartbeats is how many heartbeats in a row may time out before the renderer is considered hung.
+const missedHeartbeats = 2
+
+type RecoveryCause string
+
+const (
+	CauseCrashed      RecoveryCause = "crashed"
+	CauseUnresponsive RecoveryCause = "unresponsive"
+	CauseFrozen       RecoveryCause = "frozen"
+)
+
+type WatchdogOptions struct {
+	// HeartbeatInterval is how often the page is checked, 0 turns the watchdog off.
+	HeartbeatInterval time.Duration
+	// HeartbeatTimeout bounds the Runtime.evaluate heartbeat.
+	HeartbeatTimeout time.Duration
+	// FreezeAfter is how long the page may look exactly the same before it's reloaded, 0 turns freeze detection off.
+	FreezeAfter time.Duration
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnRecovery is called after every recovery attempt.
+	OnRecovery func(r Recovery)
+}
+
+// Recovery is a single attempt at bringing the page back.
+type Recovery struct {
+	Cause   RecoveryCause
+	Action  string
+	Attempt int
+	Err     error
+	// ActionResults are the outcomes of the launch action script, run again on the recovered page.
+	ActionResults []ActionResult
+}
+
+// watchdog keeps the page of a Display alive, reloading it when the renderer crashes, hangs or freezes.
+type watchdog struct {
+	d *Display
+	// url is the recorded URL the session was set up for.
+	url string
+
+	// current is the URL the page is on, after any navigation by the page or an action.
+	mu      sync.Mutex
+	current string
+
+	crashed chan struct{}
+
+	attempt     int
+	lastRecover time.Time
+
+	frames      frameWatch
+	missedBeats int
+
+	*WatchdogOptions
+}
+
+func newWatchdog(d *Display, url string, opts *WatchdogOptions) *watchdog {
+	if opts.HeartbeatTimeout == 0 {
+		opts.HeartbeatTimeout = 5 * time.Second
+	}
+
+	if opts.MinBackoff == 0 {
+		opts.MinBackoff = 2 * time.Second
+	}
+
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = time.Minute
+	}
+
+	return &watchdog{
+		d:               d,
+		url:             url,
+		current:         url,
+		crashed:         make(chan struct{}, 1),
+		WatchdogOptions: opts,
+	}
+}
+
+// listen picks up renderer crashes and the navigations of the page, it's registered with chromedp.ListenTarget.
+func (w *watchdog) listen(ev any) {
+	switch ev := ev.(type) {
+	case *inspector.EventTargetCrashed:
+		select {
+		case w.crashed <- struct{}{}:
+		default:
+		}
+	case *page.EventFrameNavigated:
+		// Error pages have their own scheme, the page is recovered to the last URL that actually loaded.
+		if u, err := url.Parse(ev.Frame.URL); ev.Frame.ParentID == "" && err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			w.mu.Lock()
+			w.current = ev.Frame.URL
+			w.mu.Unlock()
+		}
+	}
+}
+
+// currentURL returns the URL the page was last on.
+func (w *watchdog) currentURL() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current
+}
+
+// run checks the page until the Chrome context is done.
+func (w *watchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(w.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.crashed:
+			w.d.log.Warn("Chrome renderer crashed")
+			w.recover(ctx, CauseCrashed)
+		case <-ticker.C:
+			if cause, unhealthy := w.check(ctx); unhealthy {
+				w.recover(ctx, cause)
+			}
+		}
+	}
+}
+
+// check runs the heartbeat and, when enabled, the freeze detection.
+func (w *watchdog) check(ctx context.Context) (RecoveryCause, bool) {
+	beatCtx, cancel := context.WithTimeout(ctx, w.HeartbeatTimeout)
+	defer cancel()
+
+	var ok bool
+
+	if err := chromedp.Run(beatCtx, chromedp.Evaluate(`true`, &ok)); err != nil {
+		if ctx.Err() != nil {
+			return "", false
+		}
+
+		w.missedBeats++
+		w.d.log.Warn("Chrome heartbeat failed", "missed", w.missedBeats, "error", err)
+
+		return CauseUnresponsive, hung(w.missedBeats)
+	}
+
+	w.missedBeats = 0
+
+	if w.FreezeAfter > 0 && w.frozen(beatCtx) {
+		w.d.log.Warn("Page has not changed, it looks frozen", "for", w.FreezeAfter)
+		return CauseFrozen, true
+	}
+
+	w.attempt = settledAttempt(w.attempt, w.lastRecover, time.Now(), w.MaxBackoff)
+
+	return "", false
+}
+
+// frozen reports whether the page has looked exactly the same for FreezeAfter.
+func (w *watchdog) frozen(ctx context.Context) bool {
+	var frame []byte
+
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		frame, err = page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormatJpeg).WithQuality(20).Do(ctx)
+		return err
+	}))
+
+	if err != nil {
+		w.d.log.Debug("Failed to capture page for freeze detection", "error", err)
+		return false
+	}
+
+	return w.frames.still(frame, time.Now(), w.FreezeAfter)
+}
+
+// recover waits out the backoff, then reloads the page, falling back to navigating to it again.
+func (w *watchdog) recover(ctx context.Context, cause RecoveryCause) {
+	w.attempt++
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(backoff(w.attempt, w.MinBackoff, w.MaxBackoff)):
+	}
+
+	r := Recovery{
+		Cause:   cause,
+		Attempt: w.attempt,
+	}
+
+	for i, action := range recoveryActions(cause) {
+		if i > 0 {
+			w.d.log.Warn("Recovery failed, trying the next action", "failed", r.Action, "action", action, "error", r.Err)
+		}
+
+		r.Action = action
+
+		if r.Err = w.reload(ctx, action); r.Err == nil {
+			break
+		}
+	}
+
+	// The page starts over, so it's put back in the state the launch action script left it in, e.g. logged in and playing.
+	if r.Err == nil && len(w.d.Actions) > 0 {
+		r.ActionResults, r.Err = w.d.runActions(ctx, w.d.Actions)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if r.Err != nil {
+		w.d.log.Error("Failed to recover Chrome", "cause", cause, "action", r.Action, "attempt", r.Attempt, "error", r.Err)
+	} else {
+		w.d.log.Info("Recovered Chrome", "cause", cause, "action", r.Action, "attempt", r.Attempt)
+	}
+
+	w.lastRecover = time.Now()
+	w.missedBeats = 0
+	w.frames = frameWatch{}
+
+	if w.OnRecovery != nil {
+		w.OnRecovery(r)
+	}
+}
+
+// reload reloads the page, or navigates to the URL it was last on again.
+func (w *watchdog) reload(ctx context.Context, action string) error {
+	ctx, cancel := context.WithTimeout(ctx, w.MaxBackoff)
+	defer cancel()
+
+	switch action {
+	case "reload":
+		return chromedp.Run(ctx, chromedp.Reload())
+	case "navigate":
+		// A renderer that went away may take the scripts registered on it along, the session is set up again first.
+		// Its cookies and storage are only ever overwritten with the same values.
+		if err := w.d.applySession(ctx, w.url); err != nil {
+			return fmt.Errorf("failed to set the session up again: %w", err)
+		}
+
+		return chromedp.Run(ctx, chromedp.Navigate(w.currentURL()))
+	}
+
+	return fmt.Errorf("unknown recovery action %s", action)
+}
+
+// hung reports whether missed heartbeats in a row mean the renderer is hung, a single slow heartbeat is tolerated.
+func hung(missed int) bool {
+	return missed >= missedHeartbeats
+}
+
+// backoff returns the wait before a recovery attempt, doubling from minBackoff with every attempt up to maxBackoff.
+func backoff(attempt int, minBackoff, maxBackoff time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	b := minBackoff << (attempt - 1)
+
+	// The shift overflows on long streaks of attempts.
+	if b > maxBackoff || b <= 0 || attempt > 62 {
+		return maxBackoff
+	}
+
+	return b
+}
+
+// settledAttempt returns the attempt count once the page is healthy, a page that stays healthy for maxBackoff since
+// the last recovery earns back the shortest backoff.
+func settledAttempt(attempt int, lastRecover, now time.Time, maxBackoff time.Duration) int {
+	if attempt > 0 && now.Sub(lastRecover) > maxBackoff {
+		return 0
+	}
+
+	return attempt
+}
+
+// recoveryActions returns the actions tried in order until one succeeds.
+// A crashed renderer has no document left to reload.
+func recoveryActions(cause RecoveryCause) []string {
+	if cause == CauseCrashed {
+		return []string{"navigate"}
+	}
+
+	return []string{"reload", "navigate"}
+}
+
+// frameWatch tells a frozen page by the hash of its frames.
+type frameWatch struct {
+	last  [sha256.Size]byte
+	since time.Time
+}
+
+// still reports whether frame has been the same since at least after ago, any change starts the clock over.
+func (f *frameWatch) still(frame []byte, now time.Time, after time.Duration) bool {
+	sum := sha256.Sum256(frame)
+
+	if sum != f.last || f.since.IsZero() {
+		f.last = sum
+		f.since = now
+
+		return false
+	}
+
+	return now.Sub(f.since) >= after
+}
@@ -0,0 +1,110 @@
+package display_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHung(t *testing.T) {
+	cases := []struct {
+		missed int
+		hung   bool
+	}{
+		{missed: 0, hung: false},
+		// A single slow heartbeat is tolerated.
+		{missed: 1, hung: false},
+		{missed: 2, hung: true},
+		{missed: 5, hung: true},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.hung, display.Hung(c.missed), "missed %d", c.missed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 2 * time.Second},
+		{attempt: 1, want: 2 * time.Second},
+		{attempt: 2, want: 4 * time.Second},
+		{attempt: 3, want: 8 * time.Second},
+		{attempt: 5, want: 32 * time.Second},
+		// Capped at the max backoff.
+		{attempt: 6, want: time.Minute},
+		{attempt: 40, want: time.Minute},
+		// The shift would overflow.
+		{attempt: 100, want: time.Minute},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, display.Backoff(c.attempt, 2*time.Second, time.Minute), "attempt %d", c.attempt)
+	}
+}
+
+func TestSettledAttempt(t *testing.T) {
+	recovered := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name    string
+		attempt int
+		now     time.Time
+		want    int
+	}{
+		{name: "no recovery yet", attempt: 0, now: recovered.Add(time.Hour), want: 0},
+		{name: "healthy for less than the max backoff", attempt: 3, now: recovered.Add(30 * time.Second), want: 3},
+		{name: "healthy for exactly the max backoff", attempt: 3, now: recovered.Add(time.Minute), want: 3},
+		{name: "healthy for longer than the max backoff", attempt: 3, now: recovered.Add(time.Minute + time.Second), want: 0},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, display.SettledAttempt(c.attempt, recovered, c.now, time.Minute), c.name)
+	}
+}
+
+func TestRecoveryActions(t *testing.T) {
+	cases := []struct {
+		cause   display.RecoveryCause
+		actions []string
+	}{
+		// A crashed renderer has nothing to reload.
+		{cause: display.CauseCrashed, actions: []string{"navigate"}},
+		// Otherwise a failed reload falls back to navigating again.
+		{cause: display.CauseUnresponsive, actions: []string{"reload", "navigate"}},
+		{cause: display.CauseFrozen, actions: []string{"reload", "navigate"}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.actions, display.RecoveryActions(c.cause), string(c.cause))
+	}
+}
+
+func TestFrameWatch(t *testing.T) {
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	after := 30 * time.Second
+
+	steps := []struct {
+		name   string
+		frame  string
+		at     time.Duration
+		frozen bool
+	}{
+		{name: "first frame starts the clock", frame: "a", at: 0, frozen: false},
+		{name: "same frame, not for long enough", frame: "a", at: 20 * time.Second, frozen: false},
+		{name: "same frame for FreezeAfter", frame: "a", at: 30 * time.Second, frozen: true},
+		{name: "a change starts the clock over", frame: "b", at: 40 * time.Second, frozen: false},
+		{name: "same frame since the change, not for long enough", frame: "b", at: 60 * time.Second, frozen: false},
+		{name: "same frame since the change for FreezeAfter", frame: "b", at: 70 * time.Second, frozen: true},
+	}
+
+	f := &display.FrameWatch{}
+
+	for _, s := range steps {
+		assert.Equal(t, s.frozen, f.Still([]byte(s.frame), start.Add(s.at), after), s.name)
+	}
+}
@@ -0,0 +1,152 @@
+package drain
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/pipeline"
+	"github.com/OmGuptaIND/store"
+)
+
+type DrainerOptions struct {
+	// Timeout is how long running pipelines are given to finish before they are stopped.
+	Timeout time.Duration
+	// StartGrace is how long pipelines still starting at the timeout are waited for, they're stopped as soon as they're up.
+	StartGrace   time.Duration
+	Store        *store.AppStore
+	PollInterval time.Duration
+}
+
+// Drainer stops the node from taking new pipelines and waits for the running ones to finish.
+type Drainer struct {
+	draining atomic.Bool
+	once     sync.Once
+	done     chan struct{}
+	log      *slog.Logger
+
+	*DrainerOptions
+}
+
+// GetDrainer retrieves the drainer from the context.
+func GetDrainer(ctx *context.Context) *Drainer {
+	d, _ := (*ctx).Value(config.DrainerKey).(*Drainer)
+
+	return d
+}
+
+// NewDrainer creates a new Drainer.
+func NewDrainer(opts *DrainerOptions) *Drainer {
+	if opts.PollInterval == 0 {
+		opts.PollInterval = time.Second
+	}
+
+	if opts.StartGrace == 0 {
+		opts.StartGrace = time.Minute
+	}
+
+	return &Drainer{
+		done:           make(chan struct{}),
+		log:            logger.Component("drain"),
+		DrainerOptions: opts,
+	}
+}
+
+// Draining reports whether the node is in drain mode.
+func (d *Drainer) Draining() bool {
+	if d == nil {
+		return false
+	}
+
+	return d.draining.Load()
+}
+
+// Done returns a channel that is closed once every pipeline has stopped.
+func (d *Drainer) Done() <-chan struct{} {
+	return d.done
+}
+
+// Start puts the node in drain mode, calling it more than once has no effect.
+func (d *Drainer) Start() {
+	d.once.Do(func() {
+		d.draining.Store(true)
+		go d.run()
+	})
+}
+
+// run waits for the running pipelines to finish, stopping whatever is left after the timeout.
+func (d *Drainer) run() {
+	defer close(d.done)
+
+	d.log.Info("Draining node", "pipelines", d.Store.Count(), "timeout", d.Timeout)
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	deadline := time.After(d.Timeout)
+
+	for {
+		if d.Store.Count() == 0 {
+			d.log.Info("Node drained")
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			d.log.Warn("Drain timeout reached, stopping pipelines", "pipelines", d.Store.Count())
+			d.stopPipelines()
+			d.stopStarting(ticker.C)
+			d.log.Info("Node drained after timeout")
+			return
+		}
+	}
+}
+
+// stopStarting stops the pipelines that were still starting at the timeout once they're up, for at most StartGrace.
+// Their start can't be cut short, left alone they would come up after the drain is done.
+func (d *Drainer) stopStarting(tick <-chan time.Time) {
+	if d.Store.Count() == 0 {
+		return
+	}
+
+	d.log.Warn("Waiting for the pipelines still starting", "pipelines", d.Store.Count(), "grace", d.StartGrace)
+
+	grace := time.After(d.StartGrace)
+
+	for d.Store.Count() > 0 {
+		select {
+		case <-tick:
+			d.stopPipelines()
+		case <-grace:
+			d.log.Error("Pipelines still starting after the grace period", "pipelines", d.Store.Count())
+			return
+		}
+	}
+}
+
+// stopPipelines stops all running pipelines, completing their uploads.
+func (d *Drainer) stopPipelines() {
+	pipelines := d.Store.ListPipelines()
+
+	wg := &sync.WaitGroup{}
+
+	for id, p := range pipelines {
+		wg.Add(1)
+
+		go func(id string, p *pipeline.Pipeline) {
+			defer wg.Done()
+
+			// The pipeline's OnStop hook takes it out of the store.
+			if _, err := p.StopWithReason(pipeline.StopReasonDrain); err != nil {
+				d.log.Error("Error Occured Stopping Pipeline", logger.PipelineKey, id, "error", err)
+			}
+		}(id, p)
+	}
+
+	wg.Wait()
+}
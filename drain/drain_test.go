@@ -0,0 +1,147 @@
+package drain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/drain"
+	"github.com/OmGuptaIND/pipeline"
+	"github.com/OmGuptaIND/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainEndsWhenPipelinesFinish(t *testing.T) {
+	appStore := store.NewStore()
+
+	// A pipeline still starting counts as much as a running one.
+	assert.True(t, appStore.Reserve(0))
+
+	d := drain.NewDrainer(&drain.DrainerOptions{
+		Timeout:      time.Minute,
+		Store:        appStore,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	var nilDrainer *drain.Drainer
+	assert.False(t, nilDrainer.Draining())
+	assert.False(t, d.Draining())
+
+	d.Start()
+	d.Start()
+
+	assert.True(t, d.Draining())
+
+	select {
+	case <-d.Done():
+		t.Fatal("drain ended with a pipeline left")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	appStore.Release()
+
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatal("drain did not end once the pipelines finished")
+	}
+
+	// Drain mode sticks, the node is on its way out.
+	assert.True(t, d.Draining())
+}
+
+func TestDrainStopsPipelinesAtTheDeadline(t *testing.T) {
+	appStore := store.NewStore()
+
+	// A pipeline that never got further than starting, stopping it doesn't need a browser nor an encoder.
+	p, err := pipeline.NewPipeline(context.Background(), &pipeline.NewPipelineOptions{})
+	assert.NoError(t, err)
+
+	p.OnStop = func(p *pipeline.Pipeline) {
+		appStore.RemovePipeline(p.ID)
+	}
+
+	appStore.AddPipeLine(p.ID, p)
+
+	d := drain.NewDrainer(&drain.DrainerOptions{
+		Timeout:      100 * time.Millisecond,
+		Store:        appStore,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	d.Start()
+
+	select {
+	case <-d.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not end at the deadline")
+	}
+
+	assert.Equal(t, pipeline.StateStopped, p.Status().State)
+	assert.Equal(t, pipeline.StopReasonDrain, p.Status().StopReason)
+	assert.Zero(t, appStore.Count())
+}
+
+func TestDrainStopsPipelinesStartingAtTheDeadline(t *testing.T) {
+	appStore := store.NewStore()
+
+	// A pipeline still starting on its reserved slot when the deadline hits.
+	assert.True(t, appStore.Reserve(0))
+
+	d := drain.NewDrainer(&drain.DrainerOptions{
+		Timeout:      50 * time.Millisecond,
+		StartGrace:   time.Minute,
+		Store:        appStore,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	d.Start()
+
+	select {
+	case <-d.Done():
+		t.Fatal("drain ended with a pipeline still starting")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// It comes up, as launchPipeline adds it to the store before releasing its slot.
+	p, err := pipeline.NewPipeline(context.Background(), &pipeline.NewPipelineOptions{})
+	assert.NoError(t, err)
+
+	p.OnStop = func(p *pipeline.Pipeline) {
+		appStore.RemovePipeline(p.ID)
+	}
+
+	appStore.AddPipeLine(p.ID, p)
+	appStore.Release()
+
+	select {
+	case <-d.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not end once the starting pipeline was stopped")
+	}
+
+	assert.Equal(t, pipeline.StopReasonDrain, p.Status().StopReason)
+	assert.Zero(t, appStore.Count())
+}
+
+func TestDrainGivesUpOnPipelinesThatNeverStart(t *testing.T) {
+	appStore := store.NewStore()
+
+	assert.True(t, appStore.Reserve(0))
+	defer appStore.Release()
+
+	d := drain.NewDrainer(&drain.DrainerOptions{
+		Timeout:      20 * time.Millisecond,
+		StartGrace:   50 * time.Millisecond,
+		Store:        appStore,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	d.Start()
+
+	select {
+	case <-d.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not end after the grace period")
+	}
+}
@@ -1,7 +1,9 @@
 package env
 
 import (
-	"log"
+	"log/slog"
+	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,6 +15,34 @@ type Env struct {
 // LoadEnvironmentVariables loads environment variables
 func LoadEnvironmentVariables() (*Env, error) {
 	viper.SetDefault("ENVIRONMENT", "development")
+	viper.SetDefault("DATA_DIR", "data")
+	viper.SetDefault("MAX_PIPELINES", 0)
+	viper.SetDefault("DRAIN_TIMEOUT", "10m")
+	viper.SetDefault("MAX_RECORDING_DURATION", "0s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 10)
+	viper.SetDefault("MIN_FREE_DISK_MB", 1024)
+	viper.SetDefault("LOG_FORMAT", "text")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_BUFFER_LINES", 1000)
+	viper.SetDefault("AUDIO_MODE", "system")
+	viper.SetDefault("AUDIO_CHECK_INTERVAL", "5s")
+	viper.SetDefault("QUALITY_SILENCE_AFTER", "0s")
+	viper.SetDefault("QUALITY_BLACK_AFTER", "0s")
+	viper.SetDefault("QUALITY_FREEZE_AFTER", "0s")
+	viper.SetDefault("QUALITY_FAIL", false)
+	viper.SetDefault("BROWSER_HEARTBEAT_INTERVAL", "10s")
+	viper.SetDefault("BROWSER_HEARTBEAT_TIMEOUT", "5s")
+	viper.SetDefault("BROWSER_FREEZE_AFTER", "0s")
+	viper.SetDefault("BROWSER_HAR", false)
+	viper.SetDefault("SCREENSHOT_CACHE_TTL", "5s")
+	viper.SetDefault("POSTPROCESS_WORKERS", 1)
+	viper.SetDefault("POSTPROCESS_QUEUE_SIZE", 100)
+	viper.SetDefault("FASTSTART", "off")
+	viper.SetDefault("PREVIEW_INTERVAL", "0s")
+	viper.SetDefault("PREVIEW_WIDTH", 160)
+	viper.SetDefault("OUTPUT_FORMAT", "mp4")
+	viper.SetDefault("HLS_SEGMENT_TYPE", "fmp4")
+	viper.SetDefault("HLS_SEGMENT_DURATION", "6s")
 
 	env := &Env{}
 
@@ -26,8 +56,8 @@ func LoadEnvironmentVariables() (*Env, error) {
 	err = viper.Unmarshal(&env)
 
 	if err != nil {
-		log.Fatal("Environment can't be loaded: ", err)
-		return nil, err
+		slog.Error("Environment can't be loaded", "error", err)
+		os.Exit(1)
 	}
 
 	return env, nil
@@ -58,3 +88,153 @@ func GetBucketKeyId() string {
 func GetBucketRegion() string {
 	return viper.GetString("BUCKET_REGION")
 }
+
+// GetDataDir returns the directory used to persist node state across restarts.
+func GetDataDir() string {
+	return viper.GetString("DATA_DIR")
+}
+
+// GetMaxPipelines returns the maximum number of concurrent pipelines, 0 means unlimited.
+func GetMaxPipelines() int {
+	return viper.GetInt("MAX_PIPELINES")
+}
+
+// GetDrainTimeout returns how long running pipelines are given to finish when the node drains.
+func GetDrainTimeout() time.Duration {
+	return viper.GetDuration("DRAIN_TIMEOUT")
+}
+
+// GetMaxRecordingDuration returns the node-wide cap on how long a recording runs, 0 means no cap.
+func GetMaxRecordingDuration() time.Duration {
+	return viper.GetDuration("MAX_RECORDING_DURATION")
+}
+
+// GetWebhookUrl returns the webhook every pipeline event is sent to, empty means none.
+func GetWebhookUrl() string {
+	return viper.GetString("WEBHOOK_URL")
+}
+
+// GetWebhookSecret returns the secret webhook payloads are signed with.
+func GetWebhookSecret() string {
+	return viper.GetString("WEBHOOK_SECRET")
+}
+
+// GetMinFreeDiskBytes returns the free disk space the spool dir needs for the node to be ready.
+func GetMinFreeDiskBytes() uint64 {
+	return uint64(viper.GetInt64("MIN_FREE_DISK_MB")) * 1024 * 1024
+}
+
+// GetLogFormat returns the log output format, json or text.
+func GetLogFormat() string {
+	return viper.GetString("LOG_FORMAT")
+}
+
+// GetLogLevel returns the minimum level of the log output, e.g. debug or info.
+func GetLogLevel() string {
+	return viper.GetString("LOG_LEVEL")
+}
+
+// GetLogBufferLines returns how many log lines are kept for each pipeline.
+func GetLogBufferLines() int {
+	return viper.GetInt("LOG_BUFFER_LINES")
+}
+
+// GetWebhookMaxAttempts returns how many times a webhook delivery is tried before giving up.
+func GetWebhookMaxAttempts() int {
+	return viper.GetInt("WEBHOOK_MAX_ATTEMPTS")
+}
+
+// GetAudioMode returns how the PulseAudio daemon is run: system, user or external.
+func GetAudioMode() string {
+	return viper.GetString("AUDIO_MODE")
+}
+
+// GetAudioCheckInterval returns how often the PulseAudio daemon and the pipeline sinks are checked.
+func GetAudioCheckInterval() time.Duration {
+	return viper.GetDuration("AUDIO_CHECK_INTERVAL")
+}
+
+// GetQualitySilenceAfter returns how long a recording may stay silent before it is reported, 0 turns the check off.
+func GetQualitySilenceAfter() time.Duration {
+	return viper.GetDuration("QUALITY_SILENCE_AFTER")
+}
+
+// GetQualityBlackAfter returns how long a recording may stay black before it is reported, 0 turns the check off.
+func GetQualityBlackAfter() time.Duration {
+	return viper.GetDuration("QUALITY_BLACK_AFTER")
+}
+
+// GetQualityFreezeAfter returns how long a recording may stay frozen before it is reported, 0 turns the check off.
+func GetQualityFreezeAfter() time.Duration {
+	return viper.GetDuration("QUALITY_FREEZE_AFTER")
+}
+
+// GetQualityFail returns whether a tripped quality check fails the pipeline.
+func GetQualityFail() bool {
+	return viper.GetBool("QUALITY_FAIL")
+}
+
+// GetBrowserHeartbeatInterval returns how often the browser watchdog checks the page, 0 turns the watchdog off.
+func GetBrowserHeartbeatInterval() time.Duration {
+	return viper.GetDuration("BROWSER_HEARTBEAT_INTERVAL")
+}
+
+// GetBrowserHeartbeatTimeout returns how long the page has to answer a heartbeat.
+func GetBrowserHeartbeatTimeout() time.Duration {
+	return viper.GetDuration("BROWSER_HEARTBEAT_TIMEOUT")
+}
+
+// GetBrowserFreezeAfter returns how long the page may look unchanged before it's reloaded, 0 turns freeze detection off.
+func GetBrowserFreezeAfter() time.Duration {
+	return viper.GetDuration("BROWSER_FREEZE_AFTER")
+}
+
+// GetBrowserHAR returns whether the network activity of the page is uploaded as a HAR next to the recording.
+func GetBrowserHAR() bool {
+	return viper.GetBool("BROWSER_HAR")
+}
+
+// GetScreenshotCacheTTL returns how long a pipeline screenshot is served from the cache.
+func GetScreenshotCacheTTL() time.Duration {
+	return viper.GetDuration("SCREENSHOT_CACHE_TTL")
+}
+
+// GetPostprocessWorkers returns how many post-processing jobs run at once.
+func GetPostprocessWorkers() int {
+	return viper.GetInt("POSTPROCESS_WORKERS")
+}
+
+// GetPostprocessQueueSize returns how many recordings may wait to be post-processed.
+func GetPostprocessQueueSize() int {
+	return viper.GetInt("POSTPROCESS_QUEUE_SIZE")
+}
+
+// GetFaststart returns what's done with the faststart remux of a recording: off, add or replace.
+func GetFaststart() string {
+	return viper.GetString("FASTSTART")
+}
+
+// GetPreviewInterval returns the time between two thumbnails of the preview sprite, 0 turns previews off.
+func GetPreviewInterval() time.Duration {
+	return viper.GetDuration("PREVIEW_INTERVAL")
+}
+
+// GetPreviewWidth returns the width of a preview thumbnail.
+func GetPreviewWidth() int {
+	return viper.GetInt("PREVIEW_WIDTH")
+}
+
+// GetOutputFormat returns what recordings are written as by default, mp4 or hls.
+func GetOutputFormat() string {
+	return viper.GetString("OUTPUT_FORMAT")
+}
+
+// GetHLSSegmentType returns the default segment type of HLS recordings, fmp4 or ts.
+func GetHLSSegmentType() string {
+	return viper.GetString("HLS_SEGMENT_TYPE")
+}
+
+// GetHLSSegmentDuration returns the default segment duration of HLS recordings.
+func GetHLSSegmentDuration() time.Duration {
+	return viper.GetDuration("HLS_SEGMENT_DURATION")
+}
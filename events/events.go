@@ -0,0 +1,173 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	PipelineStarted    Type = "pipeline.started"
+	PipelineFailed     Type = "pipeline.failed"
+	PipelineStopped    Type = "pipeline.stopped"
+	PipelineState      Type = "pipeline.state"
+	PipelineError      Type = "pipeline.error"
+	EncoderSlow        Type = "encoder.slow"
+	EncoderRecovered   Type = "encoder.recovered"
+	UploadProgress     Type = "upload.progress"
+	UploadCompleted    Type = "upload.completed"
+	StreamDisconnected Type = "stream.disconnected"
+	StreamReconnected  Type = "stream.reconnected"
+	QualityAlert       Type = "quality.alert"
+	QualityRecovered   Type = "quality.recovered"
+	BrowserRecovery    Type = "browser.recovery"
+	ActionFailed       Type = "action.failed"
+
+	PostprocessCompleted Type = "postprocess.completed"
+	PostprocessFailed    Type = "postprocess.failed"
+)
+
+type Event struct {
+	ID         string         `json:"id"`
+	Type       Type           `json:"type"`
+	PipelineID string         `json:"pipeline_id"`
+	Time       time.Time      `json:"time"`
+	Data       map[string]any `json:"data,omitempty"`
+}
+
+// busKey is the context key of the Bus. It lives here rather than in config so
+// that every package, display included, can publish without an import cycle.
+type busKey struct{}
+
+// Bus fans events out to its subscribers, a slow subscriber misses events rather than blocking publishers.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter func(Event) bool
+	// handle is called by the publisher in place of sending on C.
+	handle func(Event)
+	bus    *Bus
+	once   sync.Once
+}
+
+// NewBus creates a new Bus.
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// WithBus returns a copy of ctx carrying the bus.
+func WithBus(ctx context.Context, bus *Bus) context.Context {
+	return context.WithValue(ctx, busKey{}, bus)
+}
+
+// GetBus retrieves the bus from the context, nil if there is none.
+func GetBus(ctx *context.Context) *Bus {
+	bus, _ := (*ctx).Value(busKey{}).(*Bus)
+
+	return bus
+}
+
+// Subscribe returns a subscription receiving the events that pass filter, a nil filter receives everything.
+func (b *Bus) Subscribe(buffer int, filter func(Event) bool) *Subscription {
+	ch := make(chan Event, buffer)
+
+	sub := &Subscription{
+		C:      ch,
+		ch:     ch,
+		filter: filter,
+		bus:    b,
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Handle returns a subscription calling fn with the events that pass filter, from the publisher's goroutine.
+// Nothing is ever dropped, so fn must be quick and must not publish.
+func (b *Bus) Handle(filter func(Event) bool, fn func(Event)) *Subscription {
+	ch := make(chan Event)
+
+	sub := &Subscription{
+		C:      ch,
+		ch:     ch,
+		filter: filter,
+		handle: fn,
+		bus:    b,
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Close unsubscribes and closes the subscription channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+
+		close(s.ch)
+	})
+}
+
+// Publish sends the event to every matching subscriber, publishing on a nil Bus does nothing.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+
+		if sub.handle != nil {
+			sub.handle(e)
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			slog.Warn("Event subscriber is falling behind, dropping event", "component", "events", "event", e.Type, "pipeline_id", e.PipelineID)
+		}
+	}
+}
+
+// Emit publishes a new event of the given type for a pipeline.
+func (b *Bus) Emit(pipelineID string, t Type, data map[string]any) {
+	b.Publish(Event{
+		Type:       t,
+		PipelineID: pipelineID,
+		Data:       data,
+	})
+}
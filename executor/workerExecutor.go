@@ -2,15 +2,18 @@ package executor
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
+
+	"github.com/OmGuptaIND/logger"
 )
 
 type Job struct {
-	Id        string
-	Ctx       context.Context
-	JobFunc   func() error
+	Id      string
+	Ctx     context.Context
+	JobFunc func() error
+	// OnError and OnSuccess are optional, neither is called once Ctx is done.
 	OnError   func(error)
 	OnSuccess func()
 }
@@ -26,6 +29,7 @@ type WorkerExecutor struct {
 	jobs chan Job
 	wg   *sync.WaitGroup
 	opts *WorkerExecutorOptions
+	log  *slog.Logger
 }
 
 func NewWorkerExecutor(ctx context.Context, opts *WorkerExecutorOptions) *WorkerExecutor {
@@ -34,6 +38,7 @@ func NewWorkerExecutor(ctx context.Context, opts *WorkerExecutorOptions) *Worker
 		jobs: make(chan Job),
 		wg:   &sync.WaitGroup{},
 		opts: opts,
+		log:  logger.Component("executor"),
 	}
 }
 
@@ -71,7 +76,7 @@ func (w *WorkerExecutor) Stop() {
 // Wroker spins up a worker that processes jobs from the queue.
 func (w *WorkerExecutor) spinWorker() {
 	for job := range w.jobs {
-		log.Println("New Job", job.Id)
+		w.log.Info("New Job", "job_id", job.Id)
 		w.processJob(job)
 	}
 }
@@ -81,19 +86,19 @@ func (w *WorkerExecutor) processJob(job Job) {
 	retryBackOff := w.opts.RetryBackoff
 
 	for i := 0; i <= w.opts.MaxRetries; i++ {
-		log.Println("Processing job", job.Id)
+		w.log.Info("Processing job", "job_id", job.Id)
 		err := job.JobFunc()
 
 		if err == nil {
-			log.Println("Job", job.Id, "completed successfully")
-			if job.Ctx.Err() == nil {
+			w.log.Info("Job completed successfully", "job_id", job.Id)
+			if job.Ctx.Err() == nil && job.OnSuccess != nil {
 				job.OnSuccess()
 			}
 			return
 		}
 
 		if i == w.opts.MaxRetries {
-			if job.Ctx.Err() == nil {
+			if job.Ctx.Err() == nil && job.OnError != nil {
 				job.OnError(err)
 			}
 
@@ -103,7 +108,7 @@ func (w *WorkerExecutor) processJob(job Job) {
 		if retryBackOff != 0 {
 			select {
 			case <-time.After(retryBackOff):
-				log.Println("Retrying job", job.Id, "after", retryBackOff)
+				w.log.Info("Retrying job", "job_id", job.Id, "after", retryBackOff)
 
 				retryBackOff *= 2
 				continue
@@ -111,7 +116,7 @@ func (w *WorkerExecutor) processJob(job Job) {
 				err := job.Ctx.Err()
 
 				if err != nil {
-					log.Println("Job Context is Done", job.Id, err)
+					w.log.Info("Job Context is Done", "job_id", job.Id, "error", err)
 					job.OnError(err)
 				}
 
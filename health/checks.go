@@ -0,0 +1,189 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/OmGuptaIND/audio"
+)
+
+// PulseAudio checks the PulseAudio daemon answers `pactl info`, reporting the state of the audio supervisor.
+func PulseAudio(supervisor *audio.Supervisor) Check {
+	return Check{
+		Name: "pulseaudio",
+		Run: func(ctx context.Context) Result {
+			status, err := supervisor.Probe(ctx)
+
+			details := map[string]any{
+				"mode":           status.Mode,
+				"state":          status.State,
+				"restarts":       status.Restarts,
+				"sinks":          status.Sinks,
+				"server_version": status.ServerVersion,
+			}
+
+			if err != nil {
+				return Result{
+					Status:  StatusFail,
+					Message: err.Error(),
+					Details: details,
+				}
+			}
+
+			return Result{
+				Status:  StatusOK,
+				Details: details,
+			}
+		},
+	}
+}
+
+// Binary checks a binary is on the PATH and reports the first line of its version output.
+func Binary(name string, binary string, versionArgs ...string) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) Result {
+			path, err := exec.LookPath(binary)
+
+			if err != nil {
+				return Result{
+					Status:  StatusFail,
+					Message: fmt.Sprintf("%s not found on PATH", binary),
+				}
+			}
+
+			details := map[string]any{
+				"path": path,
+			}
+
+			out, err := exec.CommandContext(ctx, path, versionArgs...).CombinedOutput()
+
+			if err != nil {
+				return Result{
+					Status:  StatusFail,
+					Message: fmt.Sprintf("%s %s failed: %v", binary, strings.Join(versionArgs, " "), err),
+					Details: details,
+				}
+			}
+
+			details["version"] = firstLine(string(out))
+
+			return Result{
+				Status:  StatusOK,
+				Details: details,
+			}
+		},
+	}
+}
+
+// DiskSpace checks the filesystem of dir has at least minFree bytes available, and warns below twice that.
+// A dir that doesn't exist yet is checked on its closest existing parent.
+func DiskSpace(name string, dir string, minFree uint64) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) Result {
+			path, err := existingParent(dir)
+
+			if err != nil {
+				return Result{Status: StatusFail, Message: err.Error()}
+			}
+
+			var stat syscall.Statfs_t
+
+			if err := syscall.Statfs(path, &stat); err != nil {
+				return Result{Status: StatusFail, Message: fmt.Sprintf("statfs %s: %v", path, err)}
+			}
+
+			free := stat.Bavail * uint64(stat.Bsize)
+
+			result := Result{
+				Status: StatusOK,
+				Details: map[string]any{
+					"path":       dir,
+					"free_bytes": free,
+					"min_bytes":  minFree,
+				},
+			}
+
+			switch {
+			case free < minFree:
+				result.Status = StatusFail
+				result.Message = "not enough free disk space"
+			case free < 2*minFree:
+				result.Status = StatusWarn
+				result.Message = "free disk space is running low"
+			}
+
+			return result
+		},
+	}
+}
+
+// Storage checks object storage is reachable through ping.
+func Storage(ping func(ctx context.Context) error) Check {
+	return Check{
+		Name: "storage",
+		Run: func(ctx context.Context) Result {
+			if err := ping(ctx); err != nil {
+				return Result{Status: StatusFail, Message: err.Error()}
+			}
+
+			return Result{Status: StatusOK}
+		},
+	}
+}
+
+// Drain fails once the node is draining, so load balancers stop sending it pipelines.
+func Drain(draining func() bool) Check {
+	return Check{
+		Name: "drain",
+		Run: func(ctx context.Context) Result {
+			if draining() {
+				return Result{Status: StatusFail, Message: "node is draining"}
+			}
+
+			return Result{Status: StatusOK}
+		},
+	}
+}
+
+// existingParent returns dir, or its closest parent that exists.
+func existingParent(dir string) (string, error) {
+	path, err := filepath.Abs(dir)
+
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(path)
+
+		if parent == path {
+			return "", fmt.Errorf("no existing parent of %s", dir)
+		}
+
+		path = parent
+	}
+}
+
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+
+	return ""
+}
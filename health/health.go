@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/config"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status     Status         `json:"status"`
+	Message    string         `json:"message,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+// Check inspects a single dependency of the node.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// Report is the outcome of every check, Ready is false as soon as one of them fails.
+type Report struct {
+	Ready     bool              `json:"ready"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+type CheckerOptions struct {
+	// Timeout bounds each check.
+	Timeout time.Duration
+	// CacheFor reuses the last report for a while, so frequent probes don't spawn a pactl per request.
+	CacheFor time.Duration
+}
+
+// Checker runs the readiness checks of the node.
+type Checker struct {
+	mu     sync.Mutex
+	checks []Check
+	last   *Report
+
+	*CheckerOptions
+}
+
+// GetChecker retrieves the health checker from the context.
+func GetChecker(ctx *context.Context) *Checker {
+	c, _ := (*ctx).Value(config.HealthKey).(*Checker)
+
+	return c
+}
+
+// NewChecker creates a new Checker.
+func NewChecker(opts *CheckerOptions, checks ...Check) *Checker {
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	return &Checker{
+		checks:         checks,
+		CheckerOptions: opts,
+	}
+}
+
+// Add registers another check.
+func (c *Checker) Add(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check)
+	c.last = nil
+}
+
+// Run runs every check concurrently, or returns the cached report when it is recent enough.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.CacheFor {
+		return *c.last
+	}
+
+	results := make([]Result, len(c.checks))
+	wg := &sync.WaitGroup{}
+
+	for i, check := range c.checks {
+		wg.Add(1)
+
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, check)
+		}(i, check)
+	}
+
+	wg.Wait()
+
+	report := Report{
+		Ready:     true,
+		Checks:    make(map[string]Result, len(c.checks)),
+		CheckedAt: time.Now().UTC(),
+	}
+
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+
+		if results[i].Status == StatusFail {
+			report.Ready = false
+		}
+	}
+
+	c.last = &report
+
+	return report
+}
+
+// runCheck runs a check within the timeout, a check that doesn't return in time fails.
+func (c *Checker) runCheck(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+
+	go func() {
+		done <- check.Run(ctx)
+	}()
+
+	var result Result
+
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Status: StatusFail, Message: "check timed out"}
+	}
+
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	return result
+}
+
+// Failing returns the names of the failed checks of a report, sorted.
+func (r Report) Failing() []string {
+	failing := make([]string, 0)
+
+	for name, result := range r.Checks {
+		if result.Status == StatusFail {
+			failing = append(failing, name)
+		}
+	}
+
+	sort.Strings(failing)
+
+	return failing
+}
@@ -0,0 +1,93 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func staticCheck(name string, status health.Status) health.Check {
+	return health.Check{
+		Name: name,
+		Run: func(ctx context.Context) health.Result {
+			return health.Result{Status: status}
+		},
+	}
+}
+
+func TestCheckerReport(t *testing.T) {
+	c := health.NewChecker(&health.CheckerOptions{},
+		staticCheck("pulseaudio", health.StatusOK),
+		staticCheck("disk", health.StatusWarn),
+	)
+
+	report := c.Run(context.Background())
+
+	assert.True(t, report.Ready, "warnings don't fail readiness")
+	assert.Equal(t, health.StatusWarn, report.Checks["disk"].Status)
+
+	c.Add(health.Storage(func(ctx context.Context) error {
+		return errors.New("bucket is not reachable")
+	}))
+
+	report = c.Run(context.Background())
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, []string{"storage"}, report.Failing())
+	assert.Equal(t, "bucket is not reachable", report.Checks["storage"].Message)
+}
+
+func TestCheckerTimesOutSlowChecks(t *testing.T) {
+	c := health.NewChecker(&health.CheckerOptions{Timeout: 10 * time.Millisecond}, health.Check{
+		Name: "slow",
+		Run: func(ctx context.Context) health.Result {
+			time.Sleep(time.Second)
+			return health.Result{Status: health.StatusOK}
+		},
+	})
+
+	report := c.Run(context.Background())
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, "check timed out", report.Checks["slow"].Message)
+}
+
+func TestCheckerCachesReport(t *testing.T) {
+	runs := 0
+
+	c := health.NewChecker(&health.CheckerOptions{CacheFor: time.Minute}, health.Check{
+		Name: "counted",
+		Run: func(ctx context.Context) health.Result {
+			runs++
+			return health.Result{Status: health.StatusOK}
+		},
+	})
+
+	c.Run(context.Background())
+	c.Run(context.Background())
+
+	assert.Equal(t, 1, runs)
+}
+
+func TestDrainCheck(t *testing.T) {
+	draining := false
+	check := health.Drain(func() bool { return draining })
+
+	assert.Equal(t, health.StatusOK, check.Run(context.Background()).Status)
+
+	draining = true
+	assert.Equal(t, health.StatusFail, check.Run(context.Background()).Status)
+}
+
+func TestDiskSpaceChecksClosestExistingParent(t *testing.T) {
+	check := health.DiskSpace("disk", t.TempDir()+"/not/created/yet", 1)
+
+	result := check.Run(context.Background())
+
+	assert.Equal(t, health.StatusOK, result.Status)
+	assert.NotZero(t, result.Details["free_bytes"])
+}
@@ -0,0 +1,76 @@
+package hls
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// PlaylistName is the file name of the playlist, locally and in the cloud.
+const PlaylistName = "index.m3u8"
+
+type SegmentType string
+
+const (
+	SegmentFMP4 SegmentType = "fmp4"
+	SegmentTS   SegmentType = "ts"
+)
+
+// DefaultOptions are the defaults of the node-wide HLS settings.
+var DefaultOptions = Options{
+	SegmentType:     SegmentFMP4,
+	SegmentDuration: 6 * time.Second,
+}
+
+// Options is how the recording is segmented.
+type Options struct {
+	// Dir is where ffmpeg writes the playlist and its segments.
+	Dir             string
+	SegmentType     SegmentType
+	SegmentDuration time.Duration
+}
+
+// Validate checks the segment type and duration.
+func (o Options) Validate() error {
+	if o.SegmentType != SegmentFMP4 && o.SegmentType != SegmentTS {
+		return fmt.Errorf("invalid segment type %q, must be fmp4 or ts", o.SegmentType)
+	}
+
+	if o.SegmentDuration < time.Second || o.SegmentDuration > time.Minute {
+		return fmt.Errorf("segment duration must be between 1s and 1m")
+	}
+
+	return nil
+}
+
+// OutputArgs returns the ffmpeg output arguments writing the recording as HLS into Dir.
+// The playlist is written to a temp file and renamed, so it's never read half written.
+func (o Options) OutputArgs() []string {
+	seconds := strconv.FormatFloat(o.SegmentDuration.Seconds(), 'f', -1, 64)
+
+	args := []string{
+		// Keyframes on segment boundaries, so every segment lasts SegmentDuration.
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%s)", seconds),
+		"-f", "hls",
+		"-hls_time", seconds,
+		"-hls_list_size", "0",
+		"-hls_playlist_type", "event",
+		"-hls_flags", "independent_segments+temp_file",
+	}
+
+	if o.SegmentType == SegmentFMP4 {
+		args = append(args,
+			"-hls_segment_type", "fmp4",
+			"-hls_fmp4_init_filename", "init.mp4",
+			"-hls_segment_filename", filepath.Join(o.Dir, "segment_%05d.m4s"),
+		)
+	} else {
+		args = append(args,
+			"-hls_segment_type", "mpegts",
+			"-hls_segment_filename", filepath.Join(o.Dir, "segment_%05d.ts"),
+		)
+	}
+
+	return append(args, "-y", filepath.Join(o.Dir, PlaylistName))
+}
@@ -0,0 +1,103 @@
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const endList = "#EXT-X-ENDLIST"
+
+// Segment is a media segment of a playlist, with the tags that describe it, like its #EXTINF.
+type Segment struct {
+	Tags []string
+	URI  string
+}
+
+// Playlist is an HLS media playlist.
+type Playlist struct {
+	// Header holds the tags before the first segment.
+	Header []string
+	// MapURI is the initialization section of fMP4 segments, empty for TS segments.
+	MapURI   string
+	Segments []Segment
+	Ended    bool
+}
+
+// ParsePlaylist parses a media playlist, tags after the last segment other than #EXT-X-ENDLIST are dropped.
+func ParsePlaylist(data []byte) (*Playlist, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		return nil, fmt.Errorf("playlist doesn't start with #EXTM3U")
+	}
+
+	pl := &Playlist{Header: []string{"#EXTM3U"}}
+
+	var tags []string
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case line == endList:
+			pl.Ended = true
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			pl.MapURI = attribute(line, "URI")
+			pl.Header = append(pl.Header, line)
+		case strings.HasPrefix(line, "#EXTINF:"), strings.HasPrefix(line, "#EXT-X-DISCONTINUITY"),
+			strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"), strings.HasPrefix(line, "#EXT-X-BYTERANGE:"):
+			tags = append(tags, line)
+		case strings.HasPrefix(line, "#"):
+			if len(pl.Segments) == 0 && len(tags) == 0 {
+				pl.Header = append(pl.Header, line)
+			}
+		default:
+			pl.Segments = append(pl.Segments, Segment{Tags: tags, URI: line})
+			tags = nil
+		}
+	}
+
+	return pl, scanner.Err()
+}
+
+// Render writes the playlist with its first n segments, closed with #EXT-X-ENDLIST when ended.
+func (pl *Playlist) Render(n int, ended bool) []byte {
+	var b bytes.Buffer
+
+	for _, line := range pl.Header {
+		b.WriteString(line + "\n")
+	}
+
+	for _, s := range pl.Segments[:min(n, len(pl.Segments))] {
+		for _, tag := range s.Tags {
+			b.WriteString(tag + "\n")
+		}
+
+		b.WriteString(s.URI + "\n")
+	}
+
+	if ended {
+		b.WriteString(endList + "\n")
+	}
+
+	return b.Bytes()
+}
+
+// attribute returns the quoted value of an attribute of a tag, e.g. URI="init.mp4".
+func attribute(tag string, name string) string {
+	_, attrs, _ := strings.Cut(tag, ":")
+
+	for _, attr := range strings.Split(attrs, ",") {
+		key, value, ok := strings.Cut(attr, "=")
+
+		if ok && strings.TrimSpace(key) == name {
+			return strings.Trim(value, `"`)
+		}
+	}
+
+	return ""
+}
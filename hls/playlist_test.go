@@ -0,0 +1,71 @@
+package hls_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/hls"
+	"github.com/stretchr/testify/assert"
+)
+
+const playlist = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:6.000000,
+segment_00000.m4s
+#EXTINF:6.000000,
+segment_00001.m4s
+#EXTINF:2.500000,
+segment_00002.m4s
+`
+
+func TestParsePlaylist(t *testing.T) {
+	pl, err := hls.ParsePlaylist([]byte(playlist))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "init.mp4", pl.MapURI)
+	assert.Len(t, pl.Segments, 3)
+	assert.Equal(t, hls.Segment{Tags: []string{"#EXTINF:2.500000,"}, URI: "segment_00002.m4s"}, pl.Segments[2])
+	assert.False(t, pl.Ended)
+
+	ended, err := hls.ParsePlaylist([]byte(playlist + "#EXT-X-ENDLIST\n"))
+
+	assert.NoError(t, err)
+	assert.True(t, ended.Ended)
+
+	_, err = hls.ParsePlaylist([]byte("segment_00000.ts\n"))
+	assert.Error(t, err)
+}
+
+func TestRenderPlaylist(t *testing.T) {
+	pl, err := hls.ParsePlaylist([]byte(playlist))
+
+	assert.NoError(t, err)
+
+	// Only the segments uploaded so far are listed.
+	expected := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:6.000000,
+segment_00000.m4s
+`
+
+	assert.Equal(t, expected, string(pl.Render(1, false)))
+	assert.Equal(t, playlist+"#EXT-X-ENDLIST\n", string(pl.Render(3, true)))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, hls.DefaultOptions.Validate())
+	assert.NoError(t, hls.Options{SegmentType: hls.SegmentTS, SegmentDuration: 2 * time.Second}.Validate())
+
+	assert.Error(t, hls.Options{SegmentType: "mp4", SegmentDuration: 6 * time.Second}.Validate())
+	assert.Error(t, hls.Options{SegmentType: hls.SegmentFMP4, SegmentDuration: 500 * time.Millisecond}.Validate())
+}
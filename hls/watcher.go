@@ -0,0 +1,328 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OmGuptaIND/cloud"
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/fsnotify/fsnotify"
+)
+
+// exitTimeout bounds the wait for ffmpeg to write its last segment once the recording is stopped.
+const exitTimeout = 15 * time.Second
+
+type NewWatcherOptions struct {
+	ID string
+	// Dir is the directory ffmpeg writes the playlist and its segments to.
+	Dir string
+	// Exited is closed once ffmpeg has exited, and written the last segment.
+	Exited <-chan struct{}
+}
+
+// Watcher uploads the segments of an HLS recording as ffmpeg finishes them, and the playlist pointing at those
+// uploaded so far, so the recording can be watched while it's in progress.
+type Watcher struct {
+	ctx    context.Context
+	log    *slog.Logger
+	client cloud.CloudClient
+	fs     *fsnotify.Watcher
+	done   chan struct{}
+
+	// published is the number of segments in the uploaded playlist.
+	published atomic.Int64
+
+	// mu serializes syncs, and guards the fields below.
+	mu            sync.Mutex
+	uploaded      map[string]bool
+	uploadedBytes int64
+	stopped       bool
+
+	*NewWatcherOptions
+}
+
+// NewWatcher creates a Watcher of the directory, which has to exist.
+func NewWatcher(ctx context.Context, opts NewWatcherOptions) (*Watcher, error) {
+	// Uploads outlive the Pipeline context, the last segments are uploaded once it's cancelled.
+	uploadCtx := context.WithoutCancel(ctx)
+
+	fs, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	if err := fs.Add(opts.Dir); err != nil {
+		fs.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", opts.Dir, err)
+	}
+
+	return &Watcher{
+		ctx:               uploadCtx,
+		log:               logger.Pipeline(opts.ID, "hls"),
+		client:            cloud.GetClient(&uploadCtx),
+		fs:                fs,
+		done:              make(chan struct{}),
+		uploaded:          make(map[string]bool),
+		NewWatcherOptions: &opts,
+	}, nil
+}
+
+// Prefix returns the object key prefix of the recording, its segments and playlist are uploaded under Prefix/.
+func (w *Watcher) Prefix() string {
+	return fmt.Sprintf("recording_%s", w.ID)
+}
+
+// GetObjectKey returns the object key of the playlist.
+func (w *Watcher) GetObjectKey() *string {
+	key := w.Key(PlaylistName)
+
+	return &key
+}
+
+// PlaylistUrl returns the URL of the playlist, once a segment has been published.
+func (w *Watcher) PlaylistUrl() string {
+	if w.Published() == 0 {
+		return ""
+	}
+
+	return w.client.ObjectUrl(w.GetObjectKey())
+}
+
+// Published returns the number of segments in the uploaded playlist.
+func (w *Watcher) Published() int {
+	return int(w.published.Load())
+}
+
+// Start watches the directory, syncing every time ffmpeg rewrites the playlist.
+func (w *Watcher) Start() {
+	go func() {
+		defer close(w.done)
+
+		for {
+			select {
+			case event, ok := <-w.fs.Events:
+				if !ok {
+					return
+				}
+
+				// The playlist is renamed into place, it lists a segment once the segment is complete.
+				if filepath.Base(event.Name) != PlaylistName || !event.Has(fsnotify.Create|fsnotify.Write) {
+					continue
+				}
+
+				if err := w.sync(false); err != nil {
+					w.log.Warn("Failed to sync playlist", "error", err)
+				}
+			case err, ok := <-w.fs.Errors:
+				if !ok {
+					return
+				}
+
+				w.log.Warn("Watcher error", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop waits for ffmpeg to exit, uploads the segments left and the playlist closed with #EXT-X-ENDLIST.
+func (w *Watcher) Stop() (*cloud.CloudUploadPartCompleted, error) {
+	w.log.Info("Stopping HLS watcher")
+
+	select {
+	case <-w.Exited:
+	case <-time.After(exitTimeout):
+		w.log.Warn("Recorder did not exit in time, publishing the segments written so far")
+	}
+
+	w.fs.Close()
+	<-w.done
+
+	if err := w.sync(true); err != nil {
+		w.log.Error("Failed to publish the final playlist, the segments are kept locally", "dir", w.Dir, "error", err)
+		return nil, err
+	}
+
+	if err := os.RemoveAll(w.Dir); err != nil {
+		w.log.Warn("Failed to remove segments", "dir", w.Dir, "error", err)
+	}
+
+	url := w.client.ObjectUrl(w.GetObjectKey())
+
+	w.log.Info("HLS recording published", "segments", w.Published(), "playlist_url", url)
+
+	return &cloud.CloudUploadPartCompleted{Recording_Url: &url}, nil
+}
+
+// Close stops watching without publishing anything, and removes the segments, for a recording that failed to start.
+func (w *Watcher) Close() {
+	w.fs.Close()
+	<-w.done
+
+	if err := os.RemoveAll(w.Dir); err != nil {
+		w.log.Warn("Failed to remove segments", "dir", w.Dir, "error", err)
+	}
+}
+
+// sync uploads the segments the playlist lists that aren't uploaded yet, in order, then the playlist pointing at them.
+// A segment failing to upload holds back the ones after it, the next sync retries it.
+func (w *Watcher) sync(final bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(w.Dir, PlaylistName))
+
+	if err != nil {
+		return fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	pl, err := ParsePlaylist(data)
+
+	if err != nil {
+		return err
+	}
+
+	if pl.MapURI != "" {
+		if err := w.uploadChunk(pl.MapURI); err != nil {
+			return err
+		}
+	}
+
+	n := 0
+	var uploadErr error
+
+	for _, s := range pl.Segments {
+		if uploadErr = w.uploadChunk(s.URI); uploadErr != nil {
+			break
+		}
+
+		n++
+	}
+
+	if n == 0 && uploadErr != nil {
+		return uploadErr
+	}
+
+	if final && n == 0 {
+		return fmt.Errorf("recording has no segments")
+	}
+
+	if n > w.Published() || final {
+		if err := w.uploadPlaylist(pl, n, final && uploadErr == nil); err != nil {
+			return err
+		}
+
+		w.published.Store(int64(n))
+	}
+
+	if final && uploadErr == nil {
+		w.stopped = true
+	}
+
+	return uploadErr
+}
+
+// uploadChunk uploads a file of the playlist unless it already is.
+func (w *Watcher) uploadChunk(name string) error {
+	if w.uploaded[name] {
+		return nil
+	}
+
+	// The playlist only lists files next to it, anything else isn't ours to upload.
+	if name != path.Base(name) {
+		return fmt.Errorf("unexpected playlist entry %q", name)
+	}
+
+	stat, err := os.Stat(filepath.Join(w.Dir, name))
+
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+
+	chunk := config.ChunkInfo{
+		RecorderID: w.ID,
+		ChunkName:  name,
+		ChunkPath:  filepath.Join(w.Dir, name),
+		ChunkSize:  stat.Size(),
+	}
+
+	key := w.Key(chunk.ChunkName)
+	start := time.Now()
+
+	if err := w.client.UploadFile(&key, chunk.ChunkPath); err != nil {
+		metrics.UploadPartFailures.Inc()
+		w.log.Error("Failed to upload segment", "segment", chunk.ChunkName, "error", err)
+
+		events.GetBus(&w.ctx).Emit(w.ID, events.PipelineError, map[string]any{
+			"component": "hls",
+			"segment":   chunk.ChunkName,
+			"error":     err.Error(),
+		})
+
+		return fmt.Errorf("failed to upload %s: %w", chunk.ChunkName, err)
+	}
+
+	metrics.UploadPartDuration.Observe(time.Since(start).Seconds())
+	metrics.UploadedBytes.Add(float64(chunk.ChunkSize))
+
+	w.uploaded[chunk.ChunkName] = true
+	w.uploadedBytes += chunk.ChunkSize
+
+	w.log.Debug("Segment uploaded", "segment", chunk.ChunkName, "size", chunk.ChunkSize)
+
+	events.GetBus(&w.ctx).Emit(w.ID, events.UploadProgress, map[string]any{
+		"segment":        chunk.ChunkName,
+		"segment_bytes":  chunk.ChunkSize,
+		"uploaded_bytes": w.uploadedBytes,
+	})
+
+	return nil
+}
+
+// uploadPlaylist uploads the playlist with its first n segments, over the previous one.
+func (w *Watcher) uploadPlaylist(pl *Playlist, n int, ended bool) error {
+	file, err := os.CreateTemp(w.Dir, "upload-*.m3u8.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(pl.Render(n, ended))
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write playlist: %w", err)
+	}
+
+	if err := w.client.UploadFile(w.GetObjectKey(), file.Name()); err != nil {
+		return fmt.Errorf("failed to upload playlist: %w", err)
+	}
+
+	w.log.Debug("Playlist uploaded", "segments", n, "ended", ended)
+
+	return nil
+}
+
+// Key returns the object key of a file uploaded under Prefix/.
+func (w *Watcher) Key(name string) string {
+	return path.Join(w.Prefix(), name)
+}
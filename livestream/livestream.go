@@ -4,17 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"sync"
 	"time"
 
 	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/OmGuptaIND/progress"
 	"github.com/google/uuid"
 )
 
+// maxReconnectBackoff caps the wait between reconnect attempts.
+const maxReconnectBackoff = 30 * time.Second
+
 type NewLivestreamOptions struct {
 	ShowFfmpegLogs bool
 	StreamUrl      string
@@ -30,23 +36,54 @@ type Livestream struct {
 
 	mtx       *sync.Mutex
 	streamCmd *exec.Cmd
+	process   *streamProcess
+	closing   bool
 	closeHook func() error
+	log       *slog.Logger
+
+	done       chan error
+	Closed     bool
+	Reconnects int
 
-	done   chan error
-	Closed bool
+	// Progress tracks the encoder statistics of the streaming process, across reconnects.
+	Progress *progress.Monitor
 
 	*NewLivestreamOptions
 }
 
+// streamProcess is a single run of the streaming ffmpeg process.
+type streamProcess struct {
+	cmd       *exec.Cmd
+	startedAt time.Time
+	exited    chan struct{}
+	err       error
+}
+
 // NewLivestream initializes a new Livestream with the specified options.
 func NewLivestream(ctx context.Context, opts NewLivestreamOptions) *Livestream {
-	return &Livestream{
+	l := &Livestream{
 		ctx:                  ctx,
 		ID:                   uuid.New().String(),
 		mtx:                  &sync.Mutex{},
 		done:                 make(chan error, 1),
+		log:                  logger.Pipeline(opts.Display.ID, "livestream").With(logger.DisplayKey, opts.GetDisplayId()),
 		NewLivestreamOptions: &opts,
 	}
+
+	l.Progress = progress.NewMonitor(&progress.MonitorOptions{
+		SlowAfter:    progress.DefaultSlowAfter,
+		OnSlowChange: l.onSlowChange,
+		OnUpdate: func(stats progress.Stats) {
+			if stats.Ended {
+				metrics.EncodeSpeed.DeleteLabelValues(l.Display.ID, "livestream")
+				return
+			}
+
+			metrics.EncodeSpeed.WithLabelValues(l.Display.ID, "livestream").Set(stats.Speed)
+		},
+	})
+
+	return l
 }
 
 // Done returns a channel that will be closed when the stream is done.
@@ -63,14 +100,29 @@ func (l *Livestream) StartStream() error {
 		return errors.New("stream already in progress")
 	}
 
-	log.Println("Staring Live Streaming")
+	l.log.Info("Starting Live Streaming")
 
 	l.Wg.Add(1)
 	go l.HandleContextCancel()
 
+	if err := l.startProcess(); err != nil {
+		return err
+	}
+
+	l.Wg.Add(1)
+	go l.superviseStream()
+
+	l.log.Info("Stream process started successfully")
+
+	return nil
+}
+
+// startProcess starts the streaming ffmpeg process, the caller must hold mtx.
+func (l *Livestream) startProcess() error {
 	cmd := exec.Command("ffmpeg",
 		"-nostdin",
-		"-loglevel", "trace",
+		"-loglevel", "info",
+		"-progress", "pipe:3",
 		"-f", "x11grab",
 		"-video_size", "1280x720",
 		"-i", l.GetDisplayId(),
@@ -87,90 +139,215 @@ func (l *Livestream) StartStream() error {
 		l.StreamUrl,
 	)
 
-	if l.ShowFfmpegLogs {
-		stderr, err := cmd.StderrPipe()
-
-		if err != nil {
-			log.Printf("Failed to get ffmpeg logs: %v", err)
-		} else {
-			copyOutput := func(writer io.Writer, reader io.Reader, name string) {
-				_, err := io.Copy(writer, reader)
-				if err != nil && err != io.EOF {
-					log.Printf("Error copying %s: %v", name, err)
-				}
-			}
-			go copyOutput(os.Stderr, stderr, "stderr")
+	// The ffmpeg stderr is kept with the pipeline logs, and printed too when ShowFfmpegLogs is set.
+	stderr, err := cmd.StderrPipe()
+
+	if err != nil {
+		l.log.Error("Failed to get ffmpeg logs", "error", err)
+	} else {
+		level := slog.LevelDebug
+
+		if l.ShowFfmpegLogs {
+			level = slog.LevelInfo
 		}
+
+		go logger.Capture(stderr, l.log.With(logger.ComponentKey, "ffmpeg"), level)
+	}
+
+	closeProgress, err := l.Progress.Attach(cmd)
+
+	if err != nil {
+		return fmt.Errorf("failed to create progress pipe: %w", err)
 	}
 
-	if err := cmd.Start(); err != nil {
-		log.Printf("Failed to start FFmpeg: %v", err)
+	err = cmd.Start()
+	closeProgress()
+
+	if err != nil {
+		l.log.Error("Failed to start FFmpeg", "error", err)
 		return err
 	}
 
+	process := &streamProcess{
+		cmd:       cmd,
+		startedAt: time.Now(),
+		exited:    make(chan struct{}),
+	}
+
 	l.Wg.Add(1)
 	go func() {
 		defer l.Wg.Done()
-		if err := cmd.Wait(); err != nil {
-			l.done <- err
-		}
+		process.err = cmd.Wait()
+		close(process.exited)
 	}()
 
 	l.streamCmd = cmd
-
-	log.Println("Stream process started successfully")
+	l.process = process
 
 	return nil
 }
 
+// superviseStream restarts the stream whenever ffmpeg exits before the Livestream is closed,
+// e.g. when the RTMP server drops the connection.
+func (l *Livestream) superviseStream() {
+	defer l.Wg.Done()
+
+	failures := 0
+
+	for {
+		l.mtx.Lock()
+		process := l.process
+		l.mtx.Unlock()
+
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-process.exited:
+		}
+
+		if l.isClosing() {
+			return
+		}
+
+		// A stream that stayed up for a while starts over with a short backoff.
+		if time.Since(process.startedAt) > maxReconnectBackoff {
+			failures = 0
+		}
+
+		l.log.Warn("Live Stream disconnected", "error", process.err)
+
+		l.bus().Emit(l.Display.ID, events.StreamDisconnected, map[string]any{
+			"stream_url": l.StreamUrl,
+			"error":      fmt.Sprint(process.err),
+		})
+
+		for attempt := 1; ; attempt++ {
+			failures++
+
+			select {
+			case <-l.ctx.Done():
+				return
+			case <-time.After(reconnectBackoff(failures)):
+			}
+
+			l.mtx.Lock()
+
+			if l.closing {
+				l.mtx.Unlock()
+				return
+			}
+
+			err := l.startProcess()
+
+			if err == nil {
+				l.Reconnects++
+				metrics.LivestreamReconnects.Inc()
+			}
+
+			l.mtx.Unlock()
+
+			if err != nil {
+				l.log.Warn("Failed to reconnect Live Stream", "attempt", attempt, "error", err)
+				continue
+			}
+
+			l.log.Info("Live Stream reconnected", "attempt", attempt)
+
+			l.bus().Emit(l.Display.ID, events.StreamReconnected, map[string]any{
+				"stream_url": l.StreamUrl,
+				"attempt":    attempt,
+			})
+
+			break
+		}
+	}
+}
+
+// reconnectBackoff doubles from a second up to maxReconnectBackoff.
+func reconnectBackoff(failures int) time.Duration {
+	backoff := time.Second << min(failures-1, 5)
+
+	return min(backoff, maxReconnectBackoff)
+}
+
+// isClosing reports whether Close has been called.
+func (l *Livestream) isClosing() bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.closing
+}
+
+// onSlowChange reports the stream falling behind real time, or catching up again.
+func (l *Livestream) onSlowChange(stats progress.Stats) {
+	eventType := events.EncoderRecovered
+
+	if stats.Slow {
+		l.log.Warn("Live Stream is encoding slower than real time, the node may be overloaded", "speed", stats.Speed)
+		eventType = events.EncoderSlow
+	}
+
+	l.bus().Emit(l.Display.ID, eventType, map[string]any{
+		"component":   "livestream",
+		"speed":       stats.Speed,
+		"fps":         stats.FPS,
+		"drop_frames": stats.DropFrames,
+	})
+}
+
+// bus returns the event bus of the Livestream, nil if there is none.
+func (l *Livestream) bus() *events.Bus {
+	return events.GetBus(&l.ctx)
+}
+
 // StopStream stops the stream.
 func (l *Livestream) Close() error {
 	if l.streamCmd == nil {
-		log.Println("Stream is not running")
+		l.log.Info("Stream is not running")
 		return nil
 	}
 
 	l.mtx.Lock()
 	defer l.mtx.Unlock()
 
-	log.Println("Stopping Stream process...")
+	l.closing = true
+
+	l.log.Info("Stopping Stream process")
 
 	defer func() {
 		if l.closeHook != nil {
 			if err := l.closeHook(); err != nil {
-				log.Printf("Error in Livestream closeHook: %v", err)
+				l.log.Error("Error in Livestream closeHook", "error", err)
 			}
 		}
 	}()
 
 	if l.streamCmd == nil || l.streamCmd.Process == nil {
-		log.Println("Streaming FFmpeg process is not running")
+		l.log.Info("Streaming FFmpeg process is not running")
 		return nil
 	}
 
 	if err := l.streamCmd.Process.Signal(os.Interrupt); err != nil {
-		return fmt.Errorf("failed to send interrupt signal: %v", err)
+		l.log.Info("Failed to send interrupt signal, stream process already exited", "error", err)
 	}
 
 	timeout := time.After(10 * time.Second)
 
 	select {
-	case err := <-l.done:
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				if exitErr.ExitCode() != 255 || exitErr.ExitCode() != -1 {
-					log.Printf("FFmpeg process exited with status: %d", exitErr.ExitCode())
-				}
+	case <-l.process.exited:
+		if exitErr, ok := l.process.err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() != 255 || exitErr.ExitCode() != -1 {
+				l.log.Info("FFmpeg process exited", "status", exitErr.ExitCode())
 			}
 		}
 	case <-timeout:
-		log.Println("Stream process did not stop in time, killing it")
+		l.log.Warn("Stream process did not stop in time, killing it")
 		if err := l.streamCmd.Process.Kill(); err != nil {
-			log.Printf("Failed to kill stream process: %v", err)
+			l.log.Error("Failed to kill stream process", "error", err)
 		}
 	}
 
-	log.Println("Live Stream process stopped")
+	l.log.Info("Live Stream process stopped")
 
 	l.streamCmd = nil
 	l.Closed = true
@@ -183,6 +360,6 @@ func (l *Livestream) Close() error {
 func (l *Livestream) HandleContextCancel() {
 	defer l.Wg.Done()
 	<-l.ctx.Done()
-	log.Println("Context Done, Stopping Stream")
+	l.log.Info("Context Done, Stopping Stream")
 	l.Close()
 }
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Entry is a single log line of a pipeline.
+type Entry struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Component string            `json:"component,omitempty"`
+	Message   string            `json:"message"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+// Ring keeps the most recent entries of a pipeline.
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRing creates a Ring holding up to size entries.
+func NewRing(size int) *Ring {
+	return &Ring{
+		entries: make([]Entry, max(size, 1)),
+	}
+}
+
+// Add appends an entry, overwriting the oldest one once the ring is full.
+func (r *Ring) Add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries returns the last n entries, oldest first, n <= 0 returns all of them.
+func (r *Ring) Entries(n int) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, 0, len(r.entries))
+
+	if r.full {
+		entries = append(entries, r.entries[r.next:]...)
+	}
+
+	entries = append(entries, r.entries[:r.next]...)
+
+	if n > 0 && n < len(entries) {
+		entries = entries[len(entries)-n:]
+	}
+
+	return entries
+}
+
+// Buffers holds a Ring per pipeline, keeping the logs of the most recent pipelines once they have stopped.
+type Buffers struct {
+	mu           sync.Mutex
+	rings        map[string]*Ring
+	order        []string
+	size         int
+	maxPipelines int
+}
+
+// buffersKey is the context key of the Buffers, kept here so display can log without an import cycle.
+type buffersKey struct{}
+
+// NewBuffers creates Buffers keeping size entries for each of the last maxPipelines pipelines.
+func NewBuffers(size int, maxPipelines int) *Buffers {
+	return &Buffers{
+		rings:        make(map[string]*Ring),
+		size:         size,
+		maxPipelines: max(maxPipelines, 1),
+	}
+}
+
+// WithBuffers returns a copy of ctx carrying the buffers.
+func WithBuffers(ctx context.Context, b *Buffers) context.Context {
+	return context.WithValue(ctx, buffersKey{}, b)
+}
+
+// GetBuffers retrieves the buffers from the context, nil if there are none.
+func GetBuffers(ctx *context.Context) *Buffers {
+	b, _ := (*ctx).Value(buffersKey{}).(*Buffers)
+
+	return b
+}
+
+// Get returns the Ring of a pipeline.
+func (b *Buffers) Get(pipelineID string) (*Ring, bool) {
+	if b == nil {
+		return nil, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ring, ok := b.rings[pipelineID]
+
+	return ring, ok
+}
+
+// add appends an entry to the Ring of a pipeline, creating it and evicting the oldest pipeline when needed.
+func (b *Buffers) add(pipelineID string, e Entry) {
+	b.mu.Lock()
+
+	ring, ok := b.rings[pipelineID]
+
+	if !ok {
+		ring = NewRing(b.size)
+		b.rings[pipelineID] = ring
+		b.order = append(b.order, pipelineID)
+
+		if len(b.order) > b.maxPipelines {
+			delete(b.rings, b.order[0])
+			b.order = b.order[1:]
+		}
+	}
+
+	b.mu.Unlock()
+
+	ring.Add(e)
+}
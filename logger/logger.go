@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Attribute keys shared by every package.
+const (
+	PipelineKey  = "pipeline_id"
+	ComponentKey = "component"
+	DisplayKey   = "display"
+)
+
+type Options struct {
+	// Format is "json" or "text".
+	Format string
+	Level  slog.Level
+	Output io.Writer
+	// Buffers receives every record carrying a pipeline ID, whatever its level.
+	Buffers *Buffers
+}
+
+// Setup installs the default slog logger and returns it.
+func Setup(opts Options) *slog.Logger {
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var base slog.Handler
+
+	if strings.EqualFold(opts.Format, "json") {
+		base = slog.NewJSONHandler(opts.Output, handlerOpts)
+	} else {
+		base = slog.NewTextHandler(opts.Output, handlerOpts)
+	}
+
+	l := slog.New(NewHandler(base, opts.Buffers))
+	slog.SetDefault(l)
+
+	return l
+}
+
+// ParseLevel parses a level name such as "debug" or "warn", defaulting to info.
+func ParseLevel(s string) slog.Level {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
+}
+
+// Component returns the default logger tagged with a component, for code that isn't scoped to a pipeline.
+func Component(name string) *slog.Logger {
+	return slog.With(ComponentKey, name)
+}
+
+// Pipeline returns the default logger tagged with a pipeline ID and component.
+func Pipeline(pipelineID string, component string) *slog.Logger {
+	return slog.With(PipelineKey, pipelineID, ComponentKey, component)
+}
+
+// Capture logs every line read from r, e.g. the stderr of a child process, until r is closed.
+func Capture(r io.Reader, l *slog.Logger, level slog.Level) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			l.Log(context.Background(), level, line)
+		}
+	}
+
+	// Keep draining after an overlong line so the writer never blocks.
+	io.Copy(io.Discard, r)
+}
+
+// Writer returns a writer whose lines are logged, close it once the writing side is done.
+func Writer(l *slog.Logger, level slog.Level) io.WriteCloser {
+	r, w := io.Pipe()
+
+	go func() {
+		Capture(r, l, level)
+		r.Close()
+	}()
+
+	return w
+}
+
+// handler passes records on to a base handler, and keeps the ones of a pipeline in its Ring.
+type handler struct {
+	base    slog.Handler
+	buffers *Buffers
+
+	pipelineID string
+	component  string
+	attrs      map[string]string
+	group      string
+}
+
+// NewHandler wraps base, copying every record carrying a pipeline ID into buffers.
+func NewHandler(base slog.Handler, buffers *Buffers) slog.Handler {
+	return &handler{
+		base:    base,
+		buffers: buffers,
+	}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	// Pipeline records are kept whatever the level, debug output like ffmpeg's included.
+	if h.buffers != nil && h.pipelineID != "" {
+		return true
+	}
+
+	return h.base.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.buffers != nil {
+		h.capture(r)
+	}
+
+	if !h.base.Enabled(ctx, r.Level) {
+		return nil
+	}
+
+	return h.base.Handle(ctx, r)
+}
+
+// capture adds the record to the Ring of its pipeline, if it has one.
+func (h *handler) capture(r slog.Record) {
+	pipelineID, component := h.pipelineID, h.component
+	attrs := make(map[string]string, len(h.attrs)+r.NumAttrs())
+
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		h.collect(a, &pipelineID, &component, attrs)
+		return true
+	})
+
+	if pipelineID == "" {
+		return
+	}
+
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+
+	h.buffers.add(pipelineID, Entry{
+		Time:      r.Time,
+		Level:     r.Level.String(),
+		Component: component,
+		Message:   r.Message,
+		Attrs:     attrs,
+	})
+}
+
+// collect sorts an attribute into the pipeline ID, the component or the other attributes.
+func (h *handler) collect(a slog.Attr, pipelineID *string, component *string, attrs map[string]string) {
+	a.Value = a.Value.Resolve()
+
+	if h.group == "" {
+		switch a.Key {
+		case PipelineKey:
+			*pipelineID = a.Value.String()
+			return
+		case ComponentKey:
+			*component = a.Value.String()
+			return
+		}
+	}
+
+	key := a.Key
+
+	if h.group != "" {
+		key = h.group + "." + key
+	}
+
+	attrs[key] = a.Value.String()
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := h.clone()
+	c.base = h.base.WithAttrs(attrs)
+
+	for _, a := range attrs {
+		h.collect(a, &c.pipelineID, &c.component, c.attrs)
+	}
+
+	return c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	c := h.clone()
+	c.base = h.base.WithGroup(name)
+
+	if c.group == "" {
+		c.group = name
+	} else {
+		c.group = c.group + "." + name
+	}
+
+	return c
+}
+
+func (h *handler) clone() *handler {
+	attrs := make(map[string]string, len(h.attrs))
+
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+
+	return &handler{
+		base:       h.base,
+		buffers:    h.buffers,
+		pipelineID: h.pipelineID,
+		component:  h.component,
+		attrs:      attrs,
+		group:      h.group,
+	}
+}
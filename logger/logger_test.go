@@ -0,0 +1,94 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/OmGuptaIND/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingKeepsMostRecentEntries(t *testing.T) {
+	ring := logger.NewRing(3)
+
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		ring.Add(logger.Entry{Message: msg})
+	}
+
+	messages := func(entries []logger.Entry) []string {
+		out := make([]string, 0, len(entries))
+		for _, e := range entries {
+			out = append(out, e.Message)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"c", "d", "e"}, messages(ring.Entries(0)))
+	assert.Equal(t, []string{"d", "e"}, messages(ring.Entries(2)))
+}
+
+func TestHandlerCapturesPipelineRecords(t *testing.T) {
+	var out bytes.Buffer
+	buffers := logger.NewBuffers(10, 10)
+
+	l := slog.New(logger.NewHandler(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}), buffers))
+
+	p := l.With(logger.PipelineKey, "pipeline_1", logger.ComponentKey, "recorder")
+	p.Info("Recorder started", "display", ":99")
+	p.Debug("frame=120 fps=30")
+	l.Info("Not a pipeline record")
+	l.Warn("Pipeline stopped", logger.PipelineKey, "pipeline_2")
+
+	ring, ok := buffers.Get("pipeline_1")
+	assert.True(t, ok)
+
+	entries := ring.Entries(0)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "Recorder started", entries[0].Message)
+	assert.Equal(t, "recorder", entries[0].Component)
+	assert.Equal(t, map[string]string{"display": ":99"}, entries[0].Attrs)
+	assert.Equal(t, "DEBUG", entries[1].Level, "debug records are kept even below the output level")
+
+	ring, ok = buffers.Get("pipeline_2")
+	assert.True(t, ok)
+	assert.Equal(t, "WARN", ring.Entries(0)[0].Level)
+
+	// The debug record is captured but not written out.
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "pipeline_1", record[logger.PipelineKey])
+}
+
+func TestBuffersEvictOldestPipeline(t *testing.T) {
+	buffers := logger.NewBuffers(10, 2)
+	l := slog.New(logger.NewHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), buffers))
+
+	for _, id := range []string{"pipeline_1", "pipeline_2", "pipeline_3"} {
+		l.Info("started", logger.PipelineKey, id)
+	}
+
+	_, ok := buffers.Get("pipeline_1")
+	assert.False(t, ok)
+
+	_, ok = buffers.Get("pipeline_3")
+	assert.True(t, ok)
+}
+
+func TestCaptureLogsLines(t *testing.T) {
+	buffers := logger.NewBuffers(10, 10)
+	l := slog.New(logger.NewHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), buffers))
+
+	logger.Capture(strings.NewReader("first line\n\nsecond line\n"), l.With(logger.PipelineKey, "pipeline_1"), slog.LevelDebug)
+
+	ring, _ := buffers.Get("pipeline_1")
+	entries := ring.Entries(0)
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "second line", entries[1].Message)
+}
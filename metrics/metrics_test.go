@@ -0,0 +1,30 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/OmGuptaIND/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// The metrics are updated where they're measured, the tests of those packages check them.
+func TestRegistry(t *testing.T) {
+	problems, err := testutil.GatherAndLint(metrics.Registry)
+
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// Plain metrics are served before anything happened, so rates start from 0.
+	count, err := testutil.GatherAndCount(metrics.Registry,
+		"recorder_upload_part_duration_seconds",
+		"recorder_upload_part_failures_total",
+		"recorder_uploaded_bytes_total",
+		"recorder_upload_inflight_bytes",
+		"recorder_livestream_reconnects_total",
+		"recorder_pulseaudio_restarts_total",
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+}
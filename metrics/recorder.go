@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry holds the metrics served on GET /metrics, along with the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+// DefaultBuckets are latency buckets in seconds.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	// Pipelines counts the active pipelines by state: starting, running or stopping.
+	Pipelines = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "recorder_pipelines",
+		Help: "Active pipelines by state.",
+	}, []string{"state"})
+
+	// PipelineStartDuration times each phase of a pipeline start: xvfb, pulse, chrome, ready, ffmpeg and livestream.
+	PipelineStartDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "recorder_pipeline_start_duration_seconds",
+		Help:    "Time taken by each phase of a pipeline start.",
+		Buckets: DefaultBuckets,
+	}, []string{"phase"})
+
+	// PipelineStartFailures counts failed pipeline starts by the phase that failed.
+	PipelineStartFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recorder_pipeline_start_failures_total",
+		Help: "Failed pipeline starts by cause.",
+	}, []string{"cause"})
+
+	UploadPartDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "recorder_upload_part_duration_seconds",
+		Help:    "Time taken to upload a recording part.",
+		Buckets: DefaultBuckets,
+	})
+
+	UploadPartFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "recorder_upload_part_failures_total",
+		Help: "Recording parts that failed to upload.",
+	})
+
+	UploadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "recorder_uploaded_bytes_total",
+		Help: "Recording bytes uploaded.",
+	})
+
+	// UploadInflightBytes is the memory held by recording parts waiting on their upload.
+	UploadInflightBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "recorder_upload_inflight_bytes",
+		Help: "Bytes of recording parts buffered for upload.",
+	})
+
+	LivestreamReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "recorder_livestream_reconnects_total",
+		Help: "Live stream reconnects after ffmpeg exited.",
+	})
+
+	// EncodeSpeed is the ffmpeg encode speed of each running pipeline, below 1 means it can't keep up with real time.
+	EncodeSpeed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "recorder_encode_speed",
+		Help: "ffmpeg encode speed relative to real time.",
+	}, []string{"pipeline_id", "component"})
+
+	// PulseAudioRestarts counts the times the audio supervisor had to start the PulseAudio daemon.
+	PulseAudioRestarts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "recorder_pulseaudio_restarts_total",
+		Help: "PulseAudio daemon (re)starts by the audio supervisor.",
+	})
+
+	// QualityAlerts counts recordings found silent, black or frozen for too long, by kind.
+	QualityAlerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recorder_quality_alerts_total",
+		Help: "Recordings found silent, black or frozen by the quality monitor.",
+	}, []string{"kind"})
+
+	// BrowserRecoveries counts page reloads after the renderer crashed, hung or froze, by cause.
+	BrowserRecoveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recorder_browser_recoveries_total",
+		Help: "Page reloads by the browser watchdog.",
+	}, []string{"cause"})
+
+	// PostprocessJobs counts the post-processing jobs run on finished recordings, by job and result.
+	PostprocessJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recorder_postprocess_jobs_total",
+		Help: "Post-processing jobs run on finished recordings.",
+	}, []string{"job", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Pipelines,
+		PipelineStartDuration,
+		PipelineStartFailures,
+		UploadPartDuration,
+		UploadPartFailures,
+		UploadedBytes,
+		UploadInflightBytes,
+		LivestreamReconnects,
+		EncodeSpeed,
+		PulseAudioRestarts,
+		QualityAlerts,
+		BrowserRecoveries,
+		PostprocessJobs,
+	)
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/OmGuptaIND/cloud"
+)
+
+// addArtifacts records the URLs of files uploaded next to the recording, by name.
+func (p *Pipeline) addArtifacts(artifacts map[string]string) {
+	p.stateMtx.Lock()
+	defer p.stateMtx.Unlock()
+
+	if p.artifacts == nil {
+		p.artifacts = make(map[string]string, len(artifacts))
+	}
+
+	for name, url := range artifacts {
+		p.artifacts[name] = url
+	}
+}
+
+// ObjectKey returns the object key of the recording, of its playlist when it's recorded as HLS.
+func (p *Pipeline) ObjectKey() string {
+	switch {
+	case p.Watcher != nil:
+		return *p.Watcher.GetObjectKey()
+	case p.Uploader != nil:
+		return *p.Uploader.GetObjectKey()
+	}
+
+	return ""
+}
+
+// sidecarKey returns the object key of an artifact uploaded next to the recording, e.g. recording_<id>.har.
+// An HLS recording keeps it under its prefix along with the playlist, e.g. recording_<id>/recording_<id>.har.
+func (p *Pipeline) sidecarKey(ext string) string {
+	if p.Watcher != nil {
+		return p.Watcher.Key(p.Watcher.Prefix() + ext)
+	}
+
+	key := *p.Uploader.GetObjectKey()
+
+	return strings.TrimSuffix(key, path.Ext(key)) + ext
+}
+
+// uploadHAR uploads the HTTP Archive of the page next to the recording, returning its object key.
+// A HAR is a debugging aid, failing to upload it doesn't fail the Pipeline.
+func (p *Pipeline) uploadHAR() string {
+	har := p.Display.HAR()
+
+	if har == nil || p.ObjectKey() == "" {
+		return ""
+	}
+
+	client := cloud.GetClient(&p.ctx)
+
+	if client == nil {
+		return ""
+	}
+
+	file, err := os.CreateTemp("", fmt.Sprintf("%s-*.har", p.ID))
+
+	if err != nil {
+		p.log.Warn("Failed to create HAR file", "error", err)
+		return ""
+	}
+
+	defer os.Remove(file.Name())
+
+	err = json.NewEncoder(file).Encode(har)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		p.log.Warn("Failed to write HAR file", "error", err)
+		return ""
+	}
+
+	key := p.sidecarKey(".har")
+
+	if err := client.UploadFile(&key, file.Name()); err != nil {
+		p.log.Warn("Failed to upload HAR", "object_key", key, "error", err)
+		return ""
+	}
+
+	p.log.Info("HAR uploaded", "object_key", key, "entries", len(har.Log.Entries))
+
+	return key
+}
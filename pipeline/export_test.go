@@ -0,0 +1,25 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/OmGuptaIND/display"
+)
+
+// ThumbnailCache exposes the thumbnail cache of a Pipeline to the tests.
+type ThumbnailCache struct {
+	c thumbnailCache
+}
+
+func (c *ThumbnailCache) Get(opts display.ScreenshotOptions, maxAge time.Duration, capture func() ([]byte, error)) (*Thumbnail, error) {
+	return c.c.get(opts, maxAge, capture)
+}
+
+// ScheduleAutoStop arms the auto stop of a Pipeline whose Chrome came up at startedAt, as Start does once it's running.
+func (p *Pipeline) ScheduleAutoStop(startedAt time.Time) {
+	p.stateMtx.Lock()
+	p.startedAt = startedAt
+	p.stateMtx.Unlock()
+
+	p.scheduleAutoStop()
+}
@@ -2,22 +2,75 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/OmGuptaIND/audio"
 	"github.com/OmGuptaIND/cloud"
 	"github.com/OmGuptaIND/config"
 	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/hls"
 	"github.com/OmGuptaIND/livestream"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/OmGuptaIND/quality"
 	"github.com/OmGuptaIND/recorder"
 	"github.com/OmGuptaIND/uploader"
 )
 
+// ErrNotRunning is returned when acting on a Pipeline that isn't running.
+var ErrNotRunning = errors.New("pipeline is not running")
+
+// lastPipelineId holds the last issued pipeline timestamp, so pipelines started within the same millisecond get unique IDs.
+var lastPipelineId atomic.Int64
+
 type NewPipelineOptions struct {
 	RecordUrl string
 	StreamUrl string
+
+	// MaxDuration stops the pipeline once it has been running this long, 0 means no limit.
+	MaxDuration time.Duration
+	// StopAt stops the pipeline at the given time, the zero value means no deadline.
+	StopAt time.Time
+
+	// QualityChecks reports, and optionally fails on, a recording that stays silent, black or frozen.
+	QualityChecks quality.Thresholds
+
+	// Watchdog reloads the page when the renderer crashes, hangs or freezes.
+	Watchdog display.WatchdogOptions
+
+	// Ready holds the recording and the stream back until the page meets it.
+	Ready display.ReadyCondition
+
+	// End lets the page stop its own recording.
+	End display.EndCondition
+
+	// Actions are run against the page before it's recorded.
+	Actions []display.Action
+
+	// Session is applied to the browser before the page is loaded.
+	Session display.Session
+
+	// Emulation makes the page render as another device, locale or timezone would.
+	Emulation display.Emulation
+
+	// Proxy routes the traffic of the browser through an HTTP or SOCKS proxy.
+	Proxy display.Proxy
+	// Network blocks, or only allows, requests of the page by URL.
+	Network display.NetworkRules
+
+	// RecordHAR uploads the network activity of the page as a HAR next to the recording.
+	RecordHAR bool
+
+	// HLS records an HLS playlist, uploaded segment by segment, instead of an MP4. Nil records an MP4.
+	HLS *hls.Options
 }
 
 type Pipeline struct {
@@ -28,10 +81,33 @@ type Pipeline struct {
 	Display    *display.Display
 	Recorder   *recorder.Recorder
 	Uploader   *uploader.Uploader
+	Watcher    *hls.Watcher
 	Livestream *livestream.Livestream
+	Quality    *quality.Monitor
 
 	mtx *sync.Mutex
 	Wg  *sync.WaitGroup
+	log *slog.Logger
+
+	// actionMtx runs one action script at a time against the page.
+	actionMtx sync.Mutex
+
+	thumbnails thumbnailCache
+
+	// stateMtx guards the fields below, mtx is held for the whole of a stop.
+	stateMtx   sync.RWMutex
+	state      State
+	startedAt  time.Time
+	stoppedAt  time.Time
+	stopReason StopReason
+	stopped    bool
+	result     *cloud.CloudUploadPartCompleted
+	stopErr    error
+	harKey     string
+	artifacts  map[string]string
+
+	// OnStop is called once the pipeline has stopped, whatever triggered the stop.
+	OnStop func(p *Pipeline)
 
 	*NewPipelineOptions
 }
@@ -40,7 +116,7 @@ type Pipeline struct {
 func NewPipeline(ctx context.Context, opts *NewPipelineOptions) (*Pipeline, error) {
 	ctx, cancel := context.WithCancel(ctx)
 
-	ID := fmt.Sprintf("pipeline_%d", time.Now().UTC().UnixMilli())
+	ID := fmt.Sprintf("pipeline_%d", nextPipelineId())
 
 	pipeLine := &Pipeline{
 		ID:                 ID,
@@ -48,25 +124,72 @@ func NewPipeline(ctx context.Context, opts *NewPipelineOptions) (*Pipeline, erro
 		cancel:             cancel,
 		Wg:                 &sync.WaitGroup{},
 		mtx:                &sync.Mutex{},
+		log:                logger.Pipeline(ID, "pipeline"),
+		state:              StateStarting,
 		NewPipelineOptions: opts,
 	}
 
+	metrics.Pipelines.WithLabelValues(string(StateStarting)).Inc()
+
 	return pipeLine, nil
 }
 
+// nextPipelineId returns the current unix millisecond, bumped past the last issued ID if needed.
+func nextPipelineId() int64 {
+	for {
+		last := lastPipelineId.Load()
+		next := time.Now().UTC().UnixMilli()
+
+		if next <= last {
+			next = last + 1
+		}
+
+		if lastPipelineId.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
 // Start: starts the Pipeline.
 func (p *Pipeline) Start() error {
 	defer func() {
 		if err := recover(); err != nil {
-			log.Println("Recovered from panic", err)
+			p.log.Error("Recovered from panic", "error", err)
 			p.Stop()
 		}
 	}()
 
+	if err := p.setup(); err != nil {
+		p.teardown()
+		p.setState(StateFailed)
+		p.bus().Emit(p.ID, events.PipelineFailed, map[string]any{
+			"error": err.Error(),
+		})
+
+		return err
+	}
+
+	p.setState(StateRunning)
+	p.scheduleAutoStop()
+
+	p.bus().Emit(p.ID, events.PipelineStarted, map[string]any{
+		"record_url": p.RecordUrl,
+		"stream_url": p.StreamUrl,
+	})
+
+	return nil
+}
+
+// setup brings up the Display, Recording and Livestream in order.
+func (p *Pipeline) setup() error {
 	if err := p.setupDisplay(); err != nil {
 		return err
 	}
 
+	if err := p.startPhase("ready", p.waitReady); err != nil {
+		return err
+	}
+
 	if err := p.setupRecording(); err != nil {
 		return err
 	}
@@ -75,9 +198,98 @@ func (p *Pipeline) Start() error {
 		return err
 	}
 
+	p.setupQuality()
+
+	if err := p.Display.WatchEnd(p.End, p.onPageEnd); err != nil {
+		return fmt.Errorf("error Watching for Page End: %w", err)
+	}
+
+	return nil
+}
+
+// onPageEnd stops the Pipeline once the page has met its end condition.
+func (p *Pipeline) onPageEnd(kind display.EndKind, detail string) {
+	if _, err := p.StopWithReason(StopReason(kind)); err != nil {
+		p.log.Error("Error Occured Stopping Pipeline on Page End", "error", err)
+	}
+}
+
+// teardown releases whatever a failed setup got to start.
+func (p *Pipeline) teardown() {
+	p.cancel()
+
+	if p.Display != nil {
+		p.Display.Close()
+	}
+
+	if p.Watcher != nil {
+		p.Watcher.Close()
+	} else if p.HLS != nil && p.HLS.Dir != "" {
+		os.RemoveAll(p.HLS.Dir)
+	}
+}
+
+// waitReady waits for the page to meet the Ready condition, or carries on after a timeout when asked to.
+func (p *Pipeline) waitReady() error {
+	err := p.Display.WaitReady(p.Ready)
+
+	if errors.Is(err, display.ErrNotReady) && p.Ready.Proceed {
+		p.log.Warn("Page is not ready, recording anyway", "error", err)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error Waiting for Page: %w", err)
+	}
+
+	return nil
+}
+
+// startPhase runs a phase of the Pipeline start, timing it, or counting its failure.
+func (p *Pipeline) startPhase(phase string, fn func() error) error {
+	start := time.Now()
+
+	if err := fn(); err != nil {
+		metrics.PipelineStartFailures.WithLabelValues(phase).Inc()
+		return err
+	}
+
+	metrics.PipelineStartDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+
 	return nil
 }
 
+// bus returns the event bus of the Pipeline, nil if there is none.
+func (p *Pipeline) bus() *events.Bus {
+	return events.GetBus(&p.ctx)
+}
+
+// scheduleAutoStop stops the pipeline once it reaches MaxDuration or StopAt, whichever comes first.
+func (p *Pipeline) scheduleAutoStop() {
+	p.stateMtx.RLock()
+	deadline, reason := p.stopDeadline()
+	p.stateMtx.RUnlock()
+
+	if deadline.IsZero() {
+		return
+	}
+
+	p.log.Info("Pipeline will stop on its own", "stop_at", deadline.UTC(), "reason", reason)
+
+	go func() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			if _, err := p.StopWithReason(reason); err != nil {
+				p.log.Error("Error Occured Auto Stopping Pipeline", "error", err)
+			}
+		case <-p.ctx.Done():
+		}
+	}()
+}
+
 // setupDisplay: sets up the Display.
 func (p *Pipeline) setupDisplay() error {
 	display := display.NewDisplay(display.DisplayOptions{
@@ -86,27 +298,115 @@ func (p *Pipeline) setupDisplay() error {
 		Width:  config.DEFAULT_DISPLAY_OPTS.Width,
 		Height: config.DEFAULT_DISPLAY_OPTS.Height,
 		Depth:  config.DEFAULT_DISPLAY_OPTS.Depth,
+		Audio:  audio.GetSupervisor(&p.ctx),
 	})
 
-	if err := display.LaunchXvfb(); err != nil {
+	display.Watchdog = p.Watchdog
+	display.Watchdog.OnRecovery = p.onBrowserRecovery
+
+	// Set right away, so a failed start can still close what was launched.
+	p.Display = display
+
+	if err := p.startPhase("xvfb", display.LaunchXvfb); err != nil {
 		return fmt.Errorf("error Launching XVFB: %w", err)
 	}
 
-	if err := display.LaunchPulseSink(); err != nil {
+	if err := p.startPhase("pulse", display.LaunchPulseSink); err != nil {
 		return fmt.Errorf("error Launching Pulse Sink: %w", err)
 	}
 
-	if _, err := display.LaunchChrome(p.RecordUrl); err != nil {
+	display.Actions = p.Actions
+	display.Session = p.Session
+	display.Emulation = p.Emulation
+	display.Proxy = p.Proxy
+	display.Network = p.Network
+	display.RecordHAR = p.RecordHAR
+
+	err := p.startPhase("chrome", func() error {
+		_, err := display.LaunchChrome(p.RecordUrl)
+		return err
+	})
+
+	p.reportActions(display.ActionResults)
+
+	if err != nil {
 		return fmt.Errorf("error Launching Chrome: %w", err)
 	}
 
-	p.Display = display
+	p.stateMtx.Lock()
+	p.startedAt = time.Now().UTC()
+	p.stateMtx.Unlock()
 
 	return nil
 }
 
+// RunActions runs an action script against the page of a running Pipeline, one script at a time.
+func (p *Pipeline) RunActions(actions []display.Action) ([]display.ActionResult, error) {
+	if state := p.Status().State; state != StateRunning {
+		return nil, fmt.Errorf("%w: pipeline is %s", ErrNotRunning, state)
+	}
+
+	p.actionMtx.Lock()
+	defer p.actionMtx.Unlock()
+
+	results, err := p.Display.RunActions(actions)
+	p.reportActions(results)
+
+	return results, err
+}
+
+// Screenshot captures the page of a running Pipeline.
+func (p *Pipeline) Screenshot() ([]byte, error) {
+	if state := p.Status().State; state != StateRunning {
+		return nil, fmt.Errorf("%w: pipeline is %s", ErrNotRunning, state)
+	}
+
+	return p.Display.Screenshot()
+}
+
+// reportActions publishes the failed steps of the action script.
+func (p *Pipeline) reportActions(results []display.ActionResult) {
+	for _, r := range results {
+		if r.Error == "" {
+			continue
+		}
+
+		p.bus().Emit(p.ID, events.ActionFailed, map[string]any{
+			"index": r.Index,
+			"type":  r.Type,
+			"error": r.Error,
+		})
+	}
+}
+
+// onBrowserRecovery publishes a recovery attempt of the page.
+func (p *Pipeline) onBrowserRecovery(r display.Recovery) {
+	metrics.BrowserRecoveries.WithLabelValues(string(r.Cause)).Inc()
+
+	data := map[string]any{
+		"cause":   r.Cause,
+		"action":  r.Action,
+		"attempt": r.Attempt,
+	}
+
+	if r.Err != nil {
+		data["error"] = r.Err.Error()
+	}
+
+	p.bus().Emit(p.ID, events.BrowserRecovery, data)
+	p.reportActions(r.ActionResults)
+}
+
 // setupRecording: sets up the Recording.
 func (p *Pipeline) setupRecording() error {
+	if p.HLS != nil {
+		p.HLS.Dir = filepath.Join(config.RECORDING_DIR, p.ID)
+
+		if err := os.MkdirAll(p.HLS.Dir, 0o755); err != nil {
+			return fmt.Errorf("error Creating HLS Directory: %w", err)
+		}
+	}
+
 	recorder, err := recorder.NewRecorder(
 		p.ctx,
 		recorder.NewRecorderOptions{
@@ -114,6 +414,7 @@ func (p *Pipeline) setupRecording() error {
 			Wg:             p.Wg,
 			Display:        p.Display,
 			ShowFfmpegLogs: false,
+			HLS:            p.HLS,
 		},
 	)
 
@@ -121,12 +422,16 @@ func (p *Pipeline) setupRecording() error {
 		return fmt.Errorf("error Creating Recorder: %w", err)
 	}
 
-	if err := recorder.StartRecording(); err != nil {
+	if err := p.startPhase("ffmpeg", recorder.StartRecording); err != nil {
 		return fmt.Errorf("error Starting Recording: %w", err)
 	}
 
 	p.Recorder = recorder
 
+	if p.HLS != nil {
+		return p.setupWatcher()
+	}
+
 	uploader, err := uploader.NewUploader(
 		p.ctx,
 		recorder.GetReader(),
@@ -134,6 +439,7 @@ func (p *Pipeline) setupRecording() error {
 	)
 
 	if err != nil {
+		metrics.PipelineStartFailures.WithLabelValues("uploader").Inc()
 		return fmt.Errorf("error Creating Uploader: %w", err)
 	}
 
@@ -145,13 +451,32 @@ func (p *Pipeline) setupRecording() error {
 		defer p.Wg.Done()
 
 		if err := p.Uploader.Start(); err != nil {
-			log.Println("Error Starting Uploader", err)
+			p.log.Error("Error Starting Uploader", "error", err)
 		}
 	}()
 
 	return nil
 }
 
+// setupWatcher: starts uploading the HLS segments as the Recorder writes them.
+func (p *Pipeline) setupWatcher() error {
+	watcher, err := hls.NewWatcher(p.ctx, hls.NewWatcherOptions{
+		ID:     p.ID,
+		Dir:    p.HLS.Dir,
+		Exited: p.Recorder.Exited(),
+	})
+
+	if err != nil {
+		metrics.PipelineStartFailures.WithLabelValues("uploader").Inc()
+		return fmt.Errorf("error Creating HLS Watcher: %w", err)
+	}
+
+	p.Watcher = watcher
+	p.Watcher.Start()
+
+	return nil
+}
+
 // setupLivestream: sets up the Livestream.
 func (p *Pipeline) setupLivestream() error {
 	if p.StreamUrl == "" {
@@ -168,7 +493,7 @@ func (p *Pipeline) setupLivestream() error {
 		},
 	)
 
-	if err := l.StartStream(); err != nil {
+	if err := p.startPhase("livestream", l.StartStream); err != nil {
 		return fmt.Errorf("error Starting Livestream: %w", err)
 	}
 
@@ -177,11 +502,97 @@ func (p *Pipeline) setupLivestream() error {
 	return nil
 }
 
-// Stop: stops the Pipeline.
+// setupQuality: starts the quality monitor, a monitor that can't start is logged rather than failing the Pipeline.
+func (p *Pipeline) setupQuality() {
+	if !p.QualityChecks.Enabled() {
+		return
+	}
+
+	m := quality.NewMonitor(p.ctx, quality.NewMonitorOptions{
+		ID:         p.ID,
+		Wg:         p.Wg,
+		Thresholds: p.QualityChecks,
+		OnAlert:    p.onQualityAlert,
+		Display:    p.Display,
+	})
+
+	if err := m.Start(); err != nil {
+		p.log.Warn("Error Starting Quality Monitor", "error", err)
+		return
+	}
+
+	p.Quality = m
+}
+
+// onQualityAlert stops the Pipeline on a tripped quality check when asked to, the alert event tells why.
+func (p *Pipeline) onQualityAlert(t quality.Transition) {
+	if !p.QualityChecks.Fail {
+		return
+	}
+
+	p.log.Warn("Failing Pipeline on quality alert", "kind", t.Kind)
+
+	// The monitor is waited on by the stop, so it can't stop the Pipeline itself.
+	go func() {
+		if _, err := p.StopWithReason(StopReasonQuality); err != nil {
+			p.log.Error("Error Occured Stopping Pipeline on Quality Alert", "error", err)
+		}
+	}()
+}
+
+// Stop: stops the Pipeline on request.
 func (p *Pipeline) Stop() (*cloud.CloudUploadPartCompleted, error) {
+	return p.StopWithReason(StopReasonRequested)
+}
+
+// StopWithReason stops the Pipeline, recording why it was stopped.
+// Stopping an already stopped Pipeline returns the result of the first stop.
+func (p *Pipeline) StopWithReason(reason StopReason) (*cloud.CloudUploadPartCompleted, error) {
 	p.mtx.Lock()
-	defer p.mtx.Unlock()
-	log.Println("Stopping Pipeline...", p.ID)
+
+	if p.stopped {
+		defer p.mtx.Unlock()
+
+		p.stateMtx.RLock()
+		defer p.stateMtx.RUnlock()
+
+		return p.result, p.stopErr
+	}
+
+	p.log.Info("Stopping Pipeline", "reason", reason)
+
+	p.stopped = true
+
+	p.stateMtx.Lock()
+	p.stopReason = reason
+	p.stateMtx.Unlock()
+	p.setState(StateStopping)
+
+	resp, err := p.shutdown()
+
+	p.stateMtx.Lock()
+	p.result = resp
+	p.stopErr = err
+	p.stoppedAt = time.Now().UTC()
+	p.stateMtx.Unlock()
+	p.setState(StateStopped)
+
+	p.mtx.Unlock()
+
+	p.emitStopped(reason, resp, err)
+
+	if p.OnStop != nil {
+		p.OnStop(p)
+	}
+
+	return resp, err
+}
+
+// shutdown tears down the Pipeline components and completes the upload.
+func (p *Pipeline) shutdown() (*cloud.CloudUploadPartCompleted, error) {
+	// The encode speed of a stopped pipeline is dropped, however far it got.
+	defer metrics.EncodeSpeed.DeleteLabelValues(p.ID, "recorder")
+	defer metrics.EncodeSpeed.DeleteLabelValues(p.ID, "livestream")
 
 	p.cancel()
 
@@ -189,14 +600,86 @@ func (p *Pipeline) Stop() (*cloud.CloudUploadPartCompleted, error) {
 		p.Display.Close()
 	}
 
-	resp, err := p.Uploader.Stop()
+	var resp *cloud.CloudUploadPartCompleted
+	var err error
+
+	switch {
+	case p.Watcher != nil:
+		resp, err = p.Watcher.Stop()
+	case p.Uploader != nil:
+		resp, err = p.Uploader.Stop()
+	default:
+		p.Wg.Wait()
+		return nil, fmt.Errorf("pipeline %s has no uploader to stop", p.ID)
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf("error Stopping Uploader: %w", err)
 	}
 
+	if harKey := p.uploadHAR(); harKey != "" {
+		p.stateMtx.Lock()
+		p.harKey = harKey
+		p.stateMtx.Unlock()
+
+		p.addArtifacts(map[string]string{"har": cloud.GetClient(&p.ctx).ObjectUrl(&harKey)})
+	}
+
 	p.Wg.Wait()
-	log.Println("Pipeline Stopped", p.ID)
+	p.log.Info("Pipeline Stopped")
 
 	return resp, nil
 }
+
+// emitStopped publishes the upload and stop events.
+func (p *Pipeline) emitStopped(reason StopReason, resp *cloud.CloudUploadPartCompleted, err error) {
+	data := map[string]any{
+		"reason": reason,
+	}
+
+	if resp != nil && resp.Recording_Url != nil {
+		uploaded := map[string]any{
+			"recording_url": *resp.Recording_Url,
+			"object_key":    p.ObjectKey(),
+		}
+
+		p.stateMtx.RLock()
+		harKey := p.harKey
+		p.stateMtx.RUnlock()
+
+		if harKey != "" {
+			uploaded["har_object_key"] = harKey
+		}
+
+		p.bus().Emit(p.ID, events.UploadCompleted, uploaded)
+
+		data["recording_url"] = *resp.Recording_Url
+	}
+
+	if err != nil {
+		data["error"] = err.Error()
+	}
+
+	p.bus().Emit(p.ID, events.PipelineStopped, data)
+}
+
+// setState updates the state of the Pipeline and publishes the transition.
+func (p *Pipeline) setState(state State) {
+	p.stateMtx.Lock()
+	previous := p.state
+	p.state = state
+	p.stateMtx.Unlock()
+
+	if previous.active() {
+		metrics.Pipelines.WithLabelValues(string(previous)).Dec()
+	}
+
+	if state.active() {
+		metrics.Pipelines.WithLabelValues(string(state)).Inc()
+	}
+
+	p.bus().Emit(p.ID, events.PipelineState, map[string]any{
+		"state":    state,
+		"previous": previous,
+	})
+}
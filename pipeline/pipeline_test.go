@@ -0,0 +1,79 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/metrics"
+	"github.com/OmGuptaIND/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineStopsAtMaxDuration(t *testing.T) {
+	p, err := pipeline.NewPipeline(context.Background(), &pipeline.NewPipelineOptions{MaxDuration: time.Second})
+	assert.NoError(t, err)
+
+	// Chrome came up a little less than the max duration ago.
+	p.ScheduleAutoStop(time.Now().Add(-900 * time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return p.Status().State == pipeline.StateStopped
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, pipeline.StopReasonMaxDuration, p.Status().StopReason)
+}
+
+func TestPipelineStopsAtTheEarliestDeadline(t *testing.T) {
+	p, err := pipeline.NewPipeline(context.Background(), &pipeline.NewPipelineOptions{
+		MaxDuration: time.Hour,
+		StopAt:      time.Now().Add(100 * time.Millisecond),
+	})
+	assert.NoError(t, err)
+
+	p.ScheduleAutoStop(time.Now())
+
+	assert.Eventually(t, func() bool {
+		return p.Status().State == pipeline.StateStopped
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, pipeline.StopReasonStopAt, p.Status().StopReason)
+}
+
+func TestPipelineWithoutDeadlineKeepsRunning(t *testing.T) {
+	p, err := pipeline.NewPipeline(context.Background(), &pipeline.NewPipelineOptions{})
+	assert.NoError(t, err)
+
+	p.ScheduleAutoStop(time.Now())
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, pipeline.StateStarting, p.Status().State)
+}
+
+func TestPipelineMetrics(t *testing.T) {
+	starting := testutil.ToFloat64(metrics.Pipelines.WithLabelValues(string(pipeline.StateStarting)))
+	series := testutil.CollectAndCount(metrics.EncodeSpeed)
+
+	p, err := pipeline.NewPipeline(context.Background(), &pipeline.NewPipelineOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, starting+1, testutil.ToFloat64(metrics.Pipelines.WithLabelValues(string(pipeline.StateStarting))))
+
+	// As the recorder and the livestream report it.
+	metrics.EncodeSpeed.WithLabelValues(p.ID, "recorder").Set(0.9)
+	metrics.EncodeSpeed.WithLabelValues(p.ID, "livestream").Set(1.1)
+
+	assert.Equal(t, series+2, testutil.CollectAndCount(metrics.EncodeSpeed))
+
+	// Stopping fails without an uploader, the series are dropped all the same.
+	_, err = p.Stop()
+	assert.Error(t, err)
+
+	assert.Equal(t, series, testutil.CollectAndCount(metrics.EncodeSpeed))
+
+	// A stopped pipeline isn't counted in any state.
+	assert.Equal(t, starting, testutil.ToFloat64(metrics.Pipelines.WithLabelValues(string(pipeline.StateStarting))))
+	assert.Zero(t, testutil.ToFloat64(metrics.Pipelines.WithLabelValues(string(pipeline.StateStopping))))
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/progress"
+	"github.com/OmGuptaIND/quality"
+)
+
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateRunning  State = "running"
+	StateStopping State = "stopping"
+	StateStopped  State = "stopped"
+	StateFailed   State = "failed"
+)
+
+// active reports whether a Pipeline in this state is still holding resources.
+func (s State) active() bool {
+	return s == StateStarting || s == StateRunning || s == StateStopping
+}
+
+type StopReason string
+
+const (
+	StopReasonRequested   StopReason = "requested"
+	StopReasonMaxDuration StopReason = "max_duration"
+	StopReasonStopAt      StopReason = "stop_at"
+	StopReasonDrain       StopReason = "drain"
+	StopReasonQuality     StopReason = "quality"
+
+	// The page met its end condition, these match the display end kinds.
+	StopReasonPageEvent  StopReason = StopReason(display.EndPageEvent)
+	StopReasonConsole    StopReason = StopReason(display.EndConsole)
+	StopReasonExpression StopReason = StopReason(display.EndExpression)
+	StopReasonMediaEnded StopReason = StopReason(display.EndMediaEnded)
+	StopReasonURL        StopReason = StopReason(display.EndURL)
+)
+
+// Status is a point in time snapshot of a Pipeline.
+type Status struct {
+	Id           string     `json:"id"`
+	State        State      `json:"state"`
+	RecordUrl    string     `json:"record_url"`
+	StreamUrl    string     `json:"stream_url,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	StopAt       *time.Time `json:"stop_at,omitempty"`
+	StoppedAt    *time.Time `json:"stopped_at,omitempty"`
+	StopReason   StopReason `json:"stop_reason,omitempty"`
+	RecordingUrl string     `json:"recording_url,omitempty"`
+
+	// PlaylistUrl is the HLS playlist of the recording, watchable while it's in progress.
+	PlaylistUrl string `json:"playlist_url,omitempty"`
+	// Segments counts the HLS segments published so far.
+	Segments int `json:"segments,omitempty"`
+
+	// RecorderStats and StreamStats are the live encoder statistics of the recording and streaming ffmpeg processes.
+	RecorderStats *progress.Stats `json:"recorder_stats,omitempty"`
+	StreamStats   *progress.Stats `json:"stream_stats,omitempty"`
+
+	// QualityAlerts are the quality checks currently tripped: silence, black or freeze.
+	QualityAlerts []quality.Kind `json:"quality_alerts,omitempty"`
+
+	// Artifacts are the URLs of the files uploaded next to the recording, like its HAR, poster and thumbnails.
+	Artifacts map[string]string `json:"artifacts,omitempty"`
+
+	// BlockedRequests counts the requests of the page failed by the network rules.
+	BlockedRequests int64 `json:"blocked_requests,omitempty"`
+}
+
+// Status returns a snapshot of the Pipeline.
+func (p *Pipeline) Status() Status {
+	p.stateMtx.RLock()
+	defer p.stateMtx.RUnlock()
+
+	status := Status{
+		Id:         p.ID,
+		State:      p.state,
+		RecordUrl:  p.RecordUrl,
+		StreamUrl:  p.StreamUrl,
+		StopReason: p.stopReason,
+	}
+
+	if !p.startedAt.IsZero() {
+		startedAt := p.startedAt
+		status.StartedAt = &startedAt
+	}
+
+	if stopAt, _ := p.stopDeadline(); !stopAt.IsZero() {
+		status.StopAt = &stopAt
+	}
+
+	if !p.stoppedAt.IsZero() {
+		stoppedAt := p.stoppedAt
+		status.StoppedAt = &stoppedAt
+	}
+
+	if len(p.artifacts) > 0 {
+		status.Artifacts = make(map[string]string, len(p.artifacts))
+
+		for name, url := range p.artifacts {
+			status.Artifacts[name] = url
+		}
+	}
+
+	if p.result != nil && p.result.Recording_Url != nil {
+		status.RecordingUrl = *p.result.Recording_Url
+	}
+
+	if p.Watcher != nil {
+		status.PlaylistUrl = p.Watcher.PlaylistUrl()
+		status.Segments = p.Watcher.Published()
+	}
+
+	if p.Recorder != nil {
+		status.RecorderStats = encoderStats(p.Recorder.Progress)
+	}
+
+	if p.Livestream != nil {
+		status.StreamStats = encoderStats(p.Livestream.Progress)
+	}
+
+	status.QualityAlerts = p.Quality.Active()
+	status.BlockedRequests = p.Display.BlockedRequests()
+
+	return status
+}
+
+// encoderStats returns the stats of a monitor, nil until ffmpeg has reported progress.
+func encoderStats(m *progress.Monitor) *progress.Stats {
+	stats := m.Stats()
+
+	if stats.UpdatedAt.IsZero() {
+		return nil
+	}
+
+	return &stats
+}
+
+// stopDeadline returns when the Pipeline stops on its own and why, the zero time means never.
+func (p *Pipeline) stopDeadline() (time.Time, StopReason) {
+	var deadline time.Time
+	var reason StopReason
+
+	if p.MaxDuration > 0 && !p.startedAt.IsZero() {
+		deadline = p.startedAt.Add(p.MaxDuration)
+		reason = StopReasonMaxDuration
+	}
+
+	if !p.StopAt.IsZero() && (deadline.IsZero() || p.StopAt.Before(deadline)) {
+		deadline = p.StopAt
+		reason = StopReasonStopAt
+	}
+
+	return deadline, reason
+}
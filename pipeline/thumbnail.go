@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+)
+
+// Thumbnail is a screenshot of a Pipeline, kept around so frequent polling doesn't capture every time.
+type Thumbnail struct {
+	Image      []byte
+	Options    display.ScreenshotOptions
+	CapturedAt time.Time
+}
+
+// thumbnailCache holds the latest Thumbnail, captures are serialized so concurrent requests share one.
+type thumbnailCache struct {
+	mu     sync.Mutex
+	latest *Thumbnail
+}
+
+// Thumbnail returns a screenshot of the running Pipeline, the latest one when it was taken with the same
+// options less than maxAge ago.
+func (p *Pipeline) Thumbnail(opts display.ScreenshotOptions, maxAge time.Duration) (*Thumbnail, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if state := p.Status().State; state != StateRunning {
+		return nil, fmt.Errorf("%w: pipeline is %s", ErrNotRunning, state)
+	}
+
+	return p.thumbnails.get(opts, maxAge, func() ([]byte, error) {
+		return p.Display.Capture(opts)
+	})
+}
+
+// get returns the latest Thumbnail when it was taken with opts less than maxAge ago, capturing a new one otherwise.
+func (c *thumbnailCache) get(opts display.ScreenshotOptions, maxAge time.Duration, capture func() ([]byte, error)) (*Thumbnail, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t := c.latest; t != nil && t.Options == opts && time.Since(t.CapturedAt) < maxAge {
+		return t, nil
+	}
+
+	image, err := capture()
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.latest = &Thumbnail{
+		Image:      image,
+		Options:    opts,
+		CapturedAt: time.Now().UTC(),
+	}
+
+	return c.latest, nil
+}
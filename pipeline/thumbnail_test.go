@@ -0,0 +1,84 @@
+package pipeline_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/pipeline"
+	"github.com/stretchr/testify/assert"
+)
+
+// counter is a capture returning a new image every time.
+func counter(captures *int) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		*captures++
+		return []byte(fmt.Sprintf("image %d", *captures)), nil
+	}
+}
+
+func TestThumbnailReusesTheLatest(t *testing.T) {
+	c := &pipeline.ThumbnailCache{}
+	captures := 0
+	png := display.ScreenshotOptions{Source: display.SourceCDP, Format: display.FormatPNG, Quality: 80, Scale: 1}
+
+	first, err := c.Get(png, time.Minute, counter(&captures))
+	assert.NoError(t, err)
+
+	second, err := c.Get(png, time.Minute, counter(&captures))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, captures)
+	assert.Same(t, first, second)
+
+	// Other options need their own capture.
+	jpeg := png
+	jpeg.Format = display.FormatJPEG
+
+	third, err := c.Get(jpeg, time.Minute, counter(&captures))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, captures)
+	assert.Equal(t, []byte("image 2"), third.Image)
+	assert.Equal(t, jpeg, third.Options)
+}
+
+func TestThumbnailExpires(t *testing.T) {
+	c := &pipeline.ThumbnailCache{}
+	captures := 0
+	opts := display.ScreenshotOptions{Source: display.SourceCDP, Format: display.FormatPNG, Quality: 80, Scale: 1}
+
+	_, err := c.Get(opts, 20*time.Millisecond, counter(&captures))
+	assert.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+
+	latest, err := c.Get(opts, 20*time.Millisecond, counter(&captures))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, captures)
+	assert.Equal(t, []byte("image 2"), latest.Image)
+
+	// A max age of 0 always captures.
+	_, err = c.Get(opts, 0, counter(&captures))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, captures)
+}
+
+func TestThumbnailFailedCaptureKeepsTheLatest(t *testing.T) {
+	c := &pipeline.ThumbnailCache{}
+	captures := 0
+	opts := display.ScreenshotOptions{Source: display.SourceCDP, Format: display.FormatPNG, Quality: 80, Scale: 1}
+
+	_, err := c.Get(opts, time.Minute, counter(&captures))
+	assert.NoError(t, err)
+
+	_, err = c.Get(opts, 0, func() ([]byte, error) { return nil, errors.New("chrome is gone") })
+	assert.Error(t, err)
+
+	latest, err := c.Get(opts, time.Minute, counter(&captures))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("image 1"), latest.Image)
+}
@@ -1,10 +1,12 @@
 package pkg
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 )
@@ -34,8 +36,57 @@ func CreateDirectory(directoryPath string) error {
 			return err
 		}
 
-		log.Printf("Created directory at %s", directoryPath)
+		slog.Info("Created directory", "path", directoryPath)
 	}
 
 	return nil
 }
+
+// WriteJSONFile atomically writes v as JSON to filePath, creating the parent directory if needed.
+// The file is only readable by its owner, the persisted requests carry their secrets.
+func WriteJSONFile(filePath string, v any) error {
+	if err := CreateDirectory(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	// A fresh temporary file, so a stale one can't hand its permissions over.
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// ReadJSONFile reads the JSON file at filePath into v, a missing file is not an error.
+func ReadJSONFile(filePath string, v any) error {
+	data, err := os.ReadFile(filePath)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
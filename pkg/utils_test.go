@@ -0,0 +1,32 @@
+package pkg_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OmGuptaIND/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "queue.json")
+
+	assert.NoError(t, pkg.WriteJSONFile(path, map[string]string{"token": "secret"}))
+	// Rewriting goes through a new file too.
+	assert.NoError(t, pkg.WriteJSONFile(path, map[string]string{"token": "rotated"}))
+
+	info, err := os.Stat(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	var v map[string]string
+
+	assert.NoError(t, pkg.ReadJSONFile(path, &v))
+	assert.Equal(t, "rotated", v["token"])
+
+	// No temporary file is left behind.
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1)
+}
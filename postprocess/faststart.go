@@ -0,0 +1,80 @@
+package postprocess
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// FaststartMode is what's done with the faststart remux of a recording.
+type FaststartMode string
+
+const (
+	// FaststartOff leaves the recording fragmented.
+	FaststartOff FaststartMode = ""
+	// FaststartAdd uploads the remux next to the recording, as recording_<id>_faststart.mp4.
+	FaststartAdd FaststartMode = "add"
+	// FaststartReplace uploads the remux over the recording.
+	FaststartReplace FaststartMode = "replace"
+)
+
+// ParseFaststartMode parses a FaststartMode, off or empty turns the remux off.
+func ParseFaststartMode(s string) (FaststartMode, error) {
+	switch s {
+	case "", "off":
+		return FaststartOff, nil
+	case string(FaststartAdd), string(FaststartReplace):
+		return FaststartMode(s), nil
+	}
+
+	return "", fmt.Errorf("invalid faststart mode %q, must be off, add or replace", s)
+}
+
+// faststart remuxes the fragmented recording into a regular MP4 with the index up front, without re-encoding,
+// so players can seek in it before it's fully downloaded.
+func (p *Processor) faststart(log *slog.Logger, rec Recording) (map[string]string, error) {
+	dir, file, err := p.download(rec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "faststart.mp4")
+
+	if _, err := p.run("ffmpeg", "-hide_banner", "-y", "-i", file,
+		"-map", "0", "-c", "copy", "-movflags", "+faststart", out); err != nil {
+		return nil, fmt.Errorf("failed to remux recording: %w", err)
+	}
+
+	// Make sure the remux is playable before it goes anywhere near the recording.
+	info, err := p.probe(out)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid remux: %w", err)
+	}
+
+	if p.Faststart == FaststartReplace {
+		key := rec.ObjectKey
+
+		if err := p.Client.UploadFile(&key, out); err != nil {
+			return nil, fmt.Errorf("failed to replace %s: %w", key, err)
+		}
+
+		log.Info("Recording replaced by its faststart remux", "object_key", key, "duration", info.Duration)
+
+		return nil, nil
+	}
+
+	key, err := p.upload(rec, out, "_faststart.mp4")
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Faststart remux uploaded", "object_key", key, "duration", info.Duration)
+
+	return map[string]string{"faststart": p.Client.ObjectUrl(&key)}, nil
+}
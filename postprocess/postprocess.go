@@ -0,0 +1,258 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/OmGuptaIND/cloud"
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/executor"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+)
+
+// ErrQueueFull is returned when too many recordings are waiting to be processed.
+var ErrQueueFull = errors.New("post-processing queue is full")
+
+// maxTracked is how many recordings the job statuses are kept for.
+const maxTracked = 100
+
+// Recording is a finished recording, uploaded under ObjectKey.
+type Recording struct {
+	ID        string
+	ObjectKey string
+}
+
+type ProcessorOptions struct {
+	// Executor runs the jobs, apart from the one starting pipelines so long jobs don't hold starts back.
+	Executor *executor.WorkerExecutor
+	Client   cloud.CloudClient
+	Bus      *events.Bus
+	// Dir is where recordings are downloaded to while they're processed.
+	Dir string
+	// MaxQueued is how many recordings may wait to be processed, 0 means no limit.
+	MaxQueued int
+
+	Faststart FaststartMode
+	Previews  PreviewOptions
+}
+
+// Processor runs post-processing jobs on finished recordings, uploading what they produce next to the recording.
+type Processor struct {
+	ctx context.Context
+	log *slog.Logger
+
+	mu       sync.Mutex
+	pending  int
+	statuses map[string][]*JobStatus
+	// order is the recordings in statuses, oldest first.
+	order []string
+
+	*ProcessorOptions
+}
+
+// job is a post-processing step, returning the URLs of what it uploaded by name.
+type job struct {
+	name string
+	run  func(log *slog.Logger, rec Recording) (map[string]string, error)
+}
+
+// GetProcessor retrieves the post-processor from the context.
+func GetProcessor(ctx *context.Context) *Processor {
+	p, _ := (*ctx).Value(config.PostprocessKey).(*Processor)
+
+	return p
+}
+
+// NewProcessor creates a new Processor, its jobs are cancelled with ctx.
+func NewProcessor(ctx context.Context, opts *ProcessorOptions) (*Processor, error) {
+	if _, err := ParseFaststartMode(string(opts.Faststart)); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	opts.Previews.defaults()
+
+	return &Processor{
+		ctx:              ctx,
+		log:              logger.Component("postprocess"),
+		statuses:         make(map[string][]*JobStatus),
+		ProcessorOptions: opts,
+	}, nil
+}
+
+// Submit queues the enabled jobs for the recording, they run one after the other so each sees the output of the
+// previous one. onArtifacts is called with the URLs of what a job uploaded.
+func (p *Processor) Submit(rec Recording, onArtifacts func(artifacts map[string]string)) {
+	if p == nil {
+		return
+	}
+
+	var jobs []job
+
+	if p.Faststart != FaststartOff {
+		jobs = append(jobs, job{name: "faststart", run: p.faststart})
+	}
+
+	if p.Previews.Enabled() {
+		jobs = append(jobs, job{name: "previews", run: p.previews})
+	}
+
+	if len(jobs) == 0 {
+		return
+	}
+
+	statuses := p.track(rec.ID, jobs)
+
+	if !p.reserve() {
+		for i, j := range jobs {
+			p.log.Warn("Post-processing job rejected", logger.PipelineKey, rec.ID, "job", j.name, "error", ErrQueueFull)
+			metrics.PostprocessJobs.WithLabelValues(j.name, "rejected").Inc()
+			p.finish(statuses[i], nil, ErrQueueFull)
+
+			p.Bus.Emit(rec.ID, events.PostprocessFailed, map[string]any{
+				"job":   j.name,
+				"error": ErrQueueFull.Error(),
+			})
+		}
+
+		return
+	}
+
+	p.enqueue(rec, jobs, statuses, onArtifacts)
+}
+
+// enqueue enqueues the first of the jobs, the rest follow once it's done, whether it failed or not.
+// Enqueue blocks until a worker is free so it's done on its own goroutine.
+func (p *Processor) enqueue(rec Recording, jobs []job, statuses []*JobStatus, onArtifacts func(map[string]string)) {
+	if len(jobs) == 0 {
+		p.release()
+		return
+	}
+
+	j, status := jobs[0], statuses[0]
+	next := func() { p.enqueue(rec, jobs[1:], statuses[1:], onArtifacts) }
+
+	log := logger.Pipeline(rec.ID, "postprocess").With("job", j.name)
+
+	var artifacts map[string]string
+
+	go p.Executor.Enqueue(executor.Job{
+		Id:  fmt.Sprintf("%s_%s", j.name, rec.ID),
+		Ctx: p.ctx,
+		JobFunc: func() error {
+			p.start(status)
+
+			var err error
+			artifacts, err = j.run(log, rec)
+			return err
+		},
+		OnSuccess: func() {
+			log.Info("Post-processing job done", "artifacts", len(artifacts))
+			metrics.PostprocessJobs.WithLabelValues(j.name, "success").Inc()
+			p.finish(status, artifacts, nil)
+
+			p.Bus.Emit(rec.ID, events.PostprocessCompleted, map[string]any{
+				"job":       j.name,
+				"artifacts": artifacts,
+			})
+
+			if onArtifacts != nil && len(artifacts) > 0 {
+				onArtifacts(artifacts)
+			}
+
+			next()
+		},
+		OnError: func(err error) {
+			log.Error("Post-processing job failed", "error", err)
+			metrics.PostprocessJobs.WithLabelValues(j.name, "failure").Inc()
+			p.finish(status, nil, err)
+
+			p.Bus.Emit(rec.ID, events.PostprocessFailed, map[string]any{
+				"job":   j.name,
+				"error": err.Error(),
+			})
+
+			next()
+		},
+	})
+}
+
+// reserve takes a place in the queue for a recording, false when it's full.
+func (p *Processor) reserve() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.MaxQueued > 0 && p.pending >= p.MaxQueued {
+		return false
+	}
+
+	p.pending++
+
+	return true
+}
+
+// release frees the place of a recording once its last job is done.
+func (p *Processor) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending--
+}
+
+// download fetches the recording into a scratch directory, which the caller removes.
+func (p *Processor) download(rec Recording) (dir string, file string, err error) {
+	dir, err = os.MkdirTemp(p.Dir, rec.ID+"-")
+
+	if err != nil {
+		return "", "", err
+	}
+
+	file = path.Join(dir, path.Base(rec.ObjectKey))
+	key := rec.ObjectKey
+
+	if err := p.Client.DownloadFile(&key, file); err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	return dir, file, nil
+}
+
+// upload uploads a file next to the recording, under its key with the suffix in place of the extension.
+func (p *Processor) upload(rec Recording, file string, suffix string) (string, error) {
+	key := strings.TrimSuffix(rec.ObjectKey, path.Ext(rec.ObjectKey)) + suffix
+
+	if err := p.Client.UploadFile(&key, file); err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+
+	return key, nil
+}
+
+// run runs a command within the Processor context, returning its stdout.
+func (p *Processor) run(name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(p.ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package postprocess_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/postprocess"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpriteCues(t *testing.T) {
+	cues := postprocess.SpriteCues(25*time.Second, 10*time.Second, 2, 1, 160, 90)
+
+	assert.Len(t, cues, 3)
+
+	assert.Equal(t, postprocess.Cue{Start: 0, End: 10 * time.Second, Sheet: 0, X: 0, Y: 0, Width: 160, Height: 90}, cues[0])
+	assert.Equal(t, postprocess.Cue{Start: 10 * time.Second, End: 20 * time.Second, Sheet: 0, X: 160, Y: 0, Width: 160, Height: 90}, cues[1])
+
+	// The third thumbnail starts a new sheet and ends with the recording.
+	assert.Equal(t, postprocess.Cue{Start: 20 * time.Second, End: 25 * time.Second, Sheet: 1, X: 0, Y: 0, Width: 160, Height: 90}, cues[2])
+
+	assert.Empty(t, postprocess.SpriteCues(0, 10*time.Second, 2, 1, 160, 90))
+}
+
+func TestWebVTT(t *testing.T) {
+	cues := postprocess.SpriteCues(time.Hour+5*time.Second, time.Hour, 10, 10, 160, 90)
+
+	track := postprocess.WebVTT(cues, func(i int) string {
+		return fmt.Sprintf("sprite_%03d.jpg", i+1)
+	})
+
+	expected := "WEBVTT\n" +
+		"\n00:00:00.000 --> 01:00:00.000\nsprite_001.jpg#xywh=0,0,160,90\n" +
+		"\n01:00:00.000 --> 01:00:05.000\nsprite_001.jpg#xywh=160,0,160,90\n"
+
+	assert.Equal(t, expected, track)
+}
+
+func TestParseFaststartMode(t *testing.T) {
+	for in, expected := range map[string]postprocess.FaststartMode{
+		"":        postprocess.FaststartOff,
+		"off":     postprocess.FaststartOff,
+		"add":     postprocess.FaststartAdd,
+		"replace": postprocess.FaststartReplace,
+	} {
+		mode, err := postprocess.ParseFaststartMode(in)
+
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, mode, in)
+	}
+
+	_, err := postprocess.ParseFaststartMode("always")
+	assert.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package postprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PreviewOptions struct {
+	// Interval is the time between two sprite thumbnails, 0 turns previews off.
+	Interval time.Duration
+	// Width of a thumbnail, the height keeps the aspect ratio of the recording.
+	Width int
+	// Columns and Rows are the thumbnails per sprite sheet, long recordings get several sheets.
+	Columns int
+	Rows    int
+}
+
+func (o *PreviewOptions) defaults() {
+	if o.Width == 0 {
+		o.Width = 160
+	}
+
+	if o.Columns == 0 {
+		o.Columns = 10
+	}
+
+	if o.Rows == 0 {
+		o.Rows = 10
+	}
+}
+
+// Enabled reports whether previews are generated.
+func (o PreviewOptions) Enabled() bool {
+	return o.Interval > 0
+}
+
+// Cue is a WebVTT cue pointing at a thumbnail within a sprite sheet.
+type Cue struct {
+	Start  time.Duration
+	End    time.Duration
+	Sheet  int
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// SpriteCues returns the cues of a recording lasting duration, with a thumbnail every interval laid out
+// in sheets of columns by rows thumbnails.
+func SpriteCues(duration time.Duration, interval time.Duration, columns int, rows int, width int, height int) []Cue {
+	if duration <= 0 || interval <= 0 {
+		return nil
+	}
+
+	count := int(math.Ceil(float64(duration) / float64(interval)))
+	perSheet := columns * rows
+	cues := make([]Cue, 0, count)
+
+	for i := 0; i < count; i++ {
+		tile := i % perSheet
+
+		cues = append(cues, Cue{
+			Start:  time.Duration(i) * interval,
+			End:    min(time.Duration(i+1)*interval, duration),
+			Sheet:  i / perSheet,
+			X:      (tile % columns) * width,
+			Y:      (tile / columns) * height,
+			Width:  width,
+			Height: height,
+		})
+	}
+
+	return cues
+}
+
+// WebVTT renders the cues as a WebVTT thumbnails track, sheet returns the URL of a sprite sheet, relative to the track.
+func WebVTT(cues []Cue, sheet func(i int) string) string {
+	var b strings.Builder
+
+	b.WriteString("WEBVTT\n")
+
+	for _, c := range cues {
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n", vttTime(c.Start), vttTime(c.End), sheet(c.Sheet), c.X, c.Y, c.Width, c.Height)
+	}
+
+	return b.String()
+}
+
+// vttTime formats a WebVTT timestamp, e.g. 01:02:03.456.
+func vttTime(d time.Duration) string {
+	ms := d.Milliseconds()
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// mediaInfo is what the previews need to know about a recording.
+type mediaInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+// probe reads the duration and the video size of a file with ffprobe.
+func (p *Processor) probe(file string) (mediaInfo, error) {
+	out, err := p.run("ffprobe", "-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=width,height:format=duration", "-of", "json", file)
+
+	if err != nil {
+		return mediaInfo{}, err
+	}
+
+	var parsed struct {
+		Streams []struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return mediaInfo{}, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	if len(parsed.Streams) == 0 || parsed.Streams[0].Width == 0 {
+		return mediaInfo{}, fmt.Errorf("recording has no video")
+	}
+
+	seconds, err := strconv.ParseFloat(parsed.Format.Duration, 64)
+
+	if err != nil || seconds <= 0 {
+		return mediaInfo{}, fmt.Errorf("recording has no duration")
+	}
+
+	return mediaInfo{
+		Duration: time.Duration(seconds * float64(time.Second)),
+		Width:    parsed.Streams[0].Width,
+		Height:   parsed.Streams[0].Height,
+	}, nil
+}
+
+// previews generates a poster, the thumbnail sprite sheets and their WebVTT track, and uploads them next to the recording.
+func (p *Processor) previews(log *slog.Logger, rec Recording) (map[string]string, error) {
+	dir, file, err := p.download(rec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	info, err := p.probe(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	o := p.Previews
+	// Even dimensions keep the encoders happy.
+	height := int(math.Round(float64(o.Width)*float64(info.Height)/float64(info.Width)/2)) * 2
+
+	log.Info("Generating previews", "duration", info.Duration, "interval", o.Interval, "width", o.Width, "height", height)
+
+	// The poster is taken a tenth of the way in, past the blank first frames of the page loading.
+	poster := filepath.Join(dir, "poster.jpg")
+
+	if _, err := p.run("ffmpeg", "-hide_banner", "-y",
+		"-ss", strconv.FormatFloat((info.Duration/10).Seconds(), 'f', 3, 64),
+		"-i", file, "-frames:v", "1", "-q:v", "3", poster); err != nil {
+		return nil, fmt.Errorf("failed to generate poster: %w", err)
+	}
+
+	if _, err := p.run("ffmpeg", "-hide_banner", "-y", "-i", file, "-an",
+		"-vf", fmt.Sprintf("fps=1/%g,scale=%d:%d,tile=%dx%d", o.Interval.Seconds(), o.Width, height, o.Columns, o.Rows),
+		"-q:v", "5", filepath.Join(dir, "sprite_%03d.jpg")); err != nil {
+		return nil, fmt.Errorf("failed to generate sprite sheets: %w", err)
+	}
+
+	sheets, err := filepath.Glob(filepath.Join(dir, "sprite_*.jpg"))
+
+	if err != nil || len(sheets) == 0 {
+		return nil, fmt.Errorf("ffmpeg generated no sprite sheets")
+	}
+
+	sort.Strings(sheets)
+
+	artifacts := make(map[string]string, len(sheets)+2)
+	keys := make([]string, len(sheets))
+
+	key, err := p.upload(rec, poster, "_poster.jpg")
+
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts["poster"] = p.Client.ObjectUrl(&key)
+
+	for i, sheet := range sheets {
+		if keys[i], err = p.upload(rec, sheet, fmt.Sprintf("_sprite_%03d.jpg", i+1)); err != nil {
+			return nil, err
+		}
+
+		artifacts[fmt.Sprintf("sprite_%03d", i+1)] = p.Client.ObjectUrl(&keys[i])
+	}
+
+	cues := SpriteCues(info.Duration, o.Interval, o.Columns, o.Rows, o.Width, height)
+
+	// The track points at the sheets by name, they're uploaded next to it.
+	track := WebVTT(cues, func(i int) string {
+		return path.Base(keys[min(i, len(keys)-1)])
+	})
+
+	vtt := filepath.Join(dir, "thumbnails.vtt")
+
+	if err := os.WriteFile(vtt, []byte(track), 0o644); err != nil {
+		return nil, err
+	}
+
+	if key, err = p.upload(rec, vtt, "_thumbnails.vtt"); err != nil {
+		return nil, err
+	}
+
+	artifacts["thumbnails"] = p.Client.ObjectUrl(&key)
+
+	return artifacts, nil
+}
@@ -0,0 +1,69 @@
+package postprocess_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/cloud"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/executor"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/OmGuptaIND/postprocess"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockedClient holds every download until release is closed, then fails it.
+type blockedClient struct {
+	cloud.CloudClient
+	release chan struct{}
+}
+
+func (c *blockedClient) DownloadFile(fileName *string, downloadPath string) error {
+	<-c.release
+	return errors.New("bucket is gone")
+}
+
+func TestProcessorCountsJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobExecutor := executor.NewWorkerExecutor(ctx, &executor.WorkerExecutorOptions{WorkerCount: 1})
+	jobExecutor.Start()
+
+	client := &blockedClient{release: make(chan struct{})}
+
+	p, err := postprocess.NewProcessor(ctx, &postprocess.ProcessorOptions{
+		Executor:  jobExecutor,
+		Client:    client,
+		Bus:       events.NewBus(),
+		Dir:       t.TempDir(),
+		MaxQueued: 1,
+		Faststart: postprocess.FaststartAdd,
+	})
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	rejected := testutil.ToFloat64(metrics.PostprocessJobs.WithLabelValues("faststart", "rejected"))
+	failed := testutil.ToFloat64(metrics.PostprocessJobs.WithLabelValues("faststart", "failure"))
+
+	p.Submit(postprocess.Recording{ID: "pipeline_1", ObjectKey: "recording_pipeline_1.mp4"}, nil)
+
+	// The first recording holds the only place in the queue.
+	p.Submit(postprocess.Recording{ID: "pipeline_2", ObjectKey: "recording_pipeline_2.mp4"}, nil)
+
+	assert.Equal(t, rejected+1, testutil.ToFloat64(metrics.PostprocessJobs.WithLabelValues("faststart", "rejected")))
+
+	close(client.release)
+
+	assert.Eventually(t, func() bool {
+		jobs, ok := p.Jobs("pipeline_1")
+		return ok && jobs[0].State == postprocess.JobFailed
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, failed+1, testutil.ToFloat64(metrics.PostprocessJobs.WithLabelValues("faststart", "failure")))
+}
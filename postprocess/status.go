@@ -0,0 +1,106 @@
+package postprocess
+
+import (
+	"maps"
+	"time"
+)
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+)
+
+// JobStatus is the progress of a post-processing job of a recording.
+type JobStatus struct {
+	Job        string            `json:"job"`
+	State      JobState          `json:"state"`
+	Error      string            `json:"error,omitempty"`
+	Artifacts  map[string]string `json:"artifacts,omitempty"`
+	QueuedAt   time.Time         `json:"queued_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// Jobs returns the status of the post-processing jobs of a recording, false when none were submitted.
+func (p *Processor) Jobs(id string) ([]JobStatus, bool) {
+	if p == nil {
+		return nil, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses, ok := p.statuses[id]
+
+	if !ok {
+		return nil, false
+	}
+
+	jobs := make([]JobStatus, len(statuses))
+
+	for i, s := range statuses {
+		jobs[i] = *s
+		jobs[i].Artifacts = maps.Clone(s.Artifacts)
+	}
+
+	return jobs, true
+}
+
+// track queues the statuses of the jobs of a recording, forgetting the oldest recording past maxTracked.
+func (p *Processor) track(id string, jobs []job) []*JobStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now().UTC()
+	statuses := make([]*JobStatus, len(jobs))
+
+	for i, j := range jobs {
+		statuses[i] = &JobStatus{Job: j.name, State: JobQueued, QueuedAt: now}
+	}
+
+	if _, ok := p.statuses[id]; !ok {
+		p.order = append(p.order, id)
+	}
+
+	p.statuses[id] = statuses
+
+	for len(p.order) > maxTracked {
+		delete(p.statuses, p.order[0])
+		p.order = p.order[1:]
+	}
+
+	return statuses
+}
+
+// start marks a job as running, again when it's retried.
+func (p *Processor) start(status *JobStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	status.State = JobRunning
+	status.StartedAt = &now
+	status.Error = ""
+}
+
+// finish marks a job as completed, or failed when err is set.
+func (p *Processor) finish(status *JobStatus, artifacts map[string]string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	status.State = JobCompleted
+	status.Artifacts = artifacts
+	status.FinishedAt = &now
+
+	if err != nil {
+		status.State = JobFailed
+		status.Error = err.Error()
+	}
+}
@@ -0,0 +1,188 @@
+package progress
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSlowAfter is how long an encoder may run below 1.0x before it is flagged slow.
+const DefaultSlowAfter = 10 * time.Second
+
+// Stats are the encoder statistics of the latest ffmpeg -progress block.
+type Stats struct {
+	Frame      int64   `json:"frame"`
+	FPS        float64 `json:"fps"`
+	Bitrate    float64 `json:"bitrate_kbps"`
+	TotalSize  int64   `json:"total_size"`
+	OutTimeUs  int64   `json:"out_time_us"`
+	DupFrames  int64   `json:"dup_frames"`
+	DropFrames int64   `json:"drop_frames"`
+	Speed      float64 `json:"speed"`
+	// Slow is set once Speed has stayed below 1.0x for the monitor's SlowAfter, the node can't keep up.
+	Slow      bool      `json:"slow"`
+	Ended     bool      `json:"ended"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OutTime returns how much media has been encoded.
+func (s Stats) OutTime() time.Duration {
+	return time.Duration(s.OutTimeUs) * time.Microsecond
+}
+
+type MonitorOptions struct {
+	// SlowAfter is how long the speed has to stay below 1.0x before the encoder is flagged slow.
+	SlowAfter time.Duration
+	// OnUpdate is called with the stats of every progress block.
+	OnUpdate func(Stats)
+	// OnSlowChange is called when the encoder is flagged slow, or recovers.
+	OnSlowChange func(Stats)
+}
+
+// Monitor tracks the progress output of an ffmpeg process.
+type Monitor struct {
+	mu        sync.RWMutex
+	stats     Stats
+	slowSince time.Time
+
+	*MonitorOptions
+}
+
+// NewMonitor creates a new Monitor.
+func NewMonitor(opts *MonitorOptions) *Monitor {
+	if opts == nil {
+		opts = &MonitorOptions{}
+	}
+
+	return &Monitor{
+		MonitorOptions: opts,
+	}
+}
+
+// Stats returns the latest stats, nil-safe so callers can skip checking whether a process is monitored.
+func (m *Monitor) Stats() Stats {
+	if m == nil {
+		return Stats{}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.stats
+}
+
+// Attach hands cmd the write end of a new pipe as fd 3, for ffmpeg's "-progress pipe:3", and runs the
+// monitor on the read end. Call it before cmd.Start, and the returned func once cmd has started or failed to.
+func (m *Monitor) Attach(cmd *exec.Cmd) (func(), error) {
+	if len(cmd.ExtraFiles) != 0 {
+		return nil, errors.New("progress pipe must be the first extra file")
+	}
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.ExtraFiles = []*os.File{w}
+
+	go func() {
+		defer r.Close()
+
+		if err := m.Run(r); err != nil {
+			slog.Warn("Failed to read ffmpeg progress", "component", "progress", "error", err)
+		}
+	}()
+
+	// The pipe reaches EOF once the process exits and the parent has let go of its write end.
+	return func() { w.Close() }, nil
+}
+
+// Run reads ffmpeg -progress output from r until it is closed.
+func (m *Monitor) Run(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	block := m.Stats()
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+
+		if !ok {
+			continue
+		}
+
+		// Every block ends with a progress line.
+		if key == "progress" {
+			block.Ended = value == "end"
+			m.update(block, time.Now())
+			continue
+		}
+
+		parseField(&block, key, value)
+	}
+
+	return scanner.Err()
+}
+
+// update stores the stats of a finished block and flags sustained slow encoding.
+func (m *Monitor) update(stats Stats, now time.Time) {
+	m.mu.Lock()
+
+	wasSlow := m.stats.Slow
+
+	switch {
+	case stats.Speed <= 0 || stats.Speed >= 1:
+		// N/A speeds, e.g. while ffmpeg is starting up, don't count either way.
+		if stats.Speed >= 1 {
+			m.slowSince = time.Time{}
+		}
+	case m.slowSince.IsZero():
+		m.slowSince = now
+	}
+
+	stats.Slow = !m.slowSince.IsZero() && now.Sub(m.slowSince) >= m.SlowAfter
+	stats.UpdatedAt = now
+	m.stats = stats
+
+	m.mu.Unlock()
+
+	if m.OnUpdate != nil {
+		m.OnUpdate(stats)
+	}
+
+	if stats.Slow != wasSlow && m.OnSlowChange != nil {
+		m.OnSlowChange(stats)
+	}
+}
+
+// parseField sets the stat of a single key=value line, unknown keys and N/A values are ignored.
+func parseField(stats *Stats, key string, value string) {
+	if value == "N/A" {
+		return
+	}
+
+	switch key {
+	case "frame":
+		stats.Frame, _ = strconv.ParseInt(value, 10, 64)
+	case "fps":
+		stats.FPS, _ = strconv.ParseFloat(value, 64)
+	case "bitrate":
+		stats.Bitrate, _ = strconv.ParseFloat(strings.TrimSuffix(value, "kbits/s"), 64)
+	case "total_size":
+		stats.TotalSize, _ = strconv.ParseInt(value, 10, 64)
+	case "out_time_us":
+		stats.OutTimeUs, _ = strconv.ParseInt(value, 10, 64)
+	case "dup_frames":
+		stats.DupFrames, _ = strconv.ParseInt(value, 10, 64)
+	case "drop_frames":
+		stats.DropFrames, _ = strconv.ParseInt(value, 10, 64)
+	case "speed":
+		stats.Speed, _ = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "x")), 64)
+	}
+}
@@ -0,0 +1,102 @@
+package progress_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+const progressOutput = `frame=120
+fps=29.97
+stream_0_0_q=28.0
+bitrate=1843.2kbits/s
+total_size=921600
+out_time_us=4000000
+out_time_ms=4000000
+out_time=00:00:04.000000
+dup_frames=2
+drop_frames=1
+speed=1.01x
+progress=continue
+frame=150
+fps=30.00
+stream_0_0_q=28.0
+bitrate=N/A
+total_size=1152000
+out_time_us=5000000
+out_time_ms=5000000
+out_time=00:00:05.000000
+dup_frames=3
+drop_frames=4
+speed=   1x
+progress=end
+`
+
+func TestMonitorParsesProgressBlocks(t *testing.T) {
+	var updates []progress.Stats
+
+	m := progress.NewMonitor(&progress.MonitorOptions{
+		OnUpdate: func(s progress.Stats) {
+			updates = append(updates, s)
+		},
+	})
+
+	assert.NoError(t, m.Run(strings.NewReader(progressOutput)))
+	assert.Len(t, updates, 2)
+
+	first := updates[0]
+	assert.Equal(t, int64(120), first.Frame)
+	assert.Equal(t, 29.97, first.FPS)
+	assert.Equal(t, 1843.2, first.Bitrate)
+	assert.Equal(t, int64(921600), first.TotalSize)
+	assert.Equal(t, "4s", first.OutTime().String())
+	assert.Equal(t, int64(2), first.DupFrames)
+	assert.Equal(t, int64(1), first.DropFrames)
+	assert.Equal(t, 1.01, first.Speed)
+	assert.False(t, first.Ended)
+
+	last := m.Stats()
+	assert.Equal(t, int64(150), last.Frame)
+	assert.Equal(t, 1843.2, last.Bitrate, "an N/A bitrate keeps the last known value")
+	assert.Equal(t, int64(4), last.DropFrames)
+	assert.Equal(t, 1.0, last.Speed)
+	assert.True(t, last.Ended)
+	assert.False(t, last.Slow)
+}
+
+func TestMonitorFlagsSlowEncoding(t *testing.T) {
+	var changes []bool
+
+	m := progress.NewMonitor(&progress.MonitorOptions{
+		OnSlowChange: func(s progress.Stats) {
+			changes = append(changes, s.Slow)
+		},
+	})
+
+	output := "speed=0.8x\nprogress=continue\n" +
+		"speed=N/A\nprogress=continue\n" +
+		"speed=0.7x\nprogress=continue\n" +
+		"speed=1.2x\nprogress=continue\n"
+
+	assert.NoError(t, m.Run(strings.NewReader(output)))
+	assert.Equal(t, []bool{true, false}, changes)
+	assert.False(t, m.Stats().Slow)
+}
+
+func TestMonitorWaitsForSustainedSlowness(t *testing.T) {
+	m := progress.NewMonitor(&progress.MonitorOptions{
+		SlowAfter: time.Hour,
+	})
+
+	assert.NoError(t, m.Run(strings.NewReader("speed=0.5x\nprogress=continue\n")))
+	assert.False(t, m.Stats().Slow)
+}
+
+func TestNilMonitorStats(t *testing.T) {
+	var m *progress.Monitor
+
+	assert.Equal(t, progress.Stats{}, m.Stats())
+}
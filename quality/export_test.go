@@ -0,0 +1,6 @@
+package quality
+
+// Feed passes a line of analysis output to the Monitor, as its ffmpeg process does.
+func (m *Monitor) Feed(line string) {
+	m.feed(line)
+}
@@ -0,0 +1,149 @@
+package quality
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+)
+
+type NewMonitorOptions struct {
+	ID         string
+	Wg         *sync.WaitGroup
+	Thresholds Thresholds
+
+	// OnAlert is called when a check trips.
+	OnAlert func(t Transition)
+
+	*display.Display
+}
+
+// Monitor samples the display and the Pulse monitor of a pipeline with a low rate ffmpeg process,
+// reporting a recording that stays silent, black or frozen for too long.
+type Monitor struct {
+	ctx context.Context
+	mtx sync.Mutex
+	log *slog.Logger
+
+	detector *Detector
+
+	*NewMonitorOptions
+}
+
+// NewMonitor creates a new Monitor.
+func NewMonitor(ctx context.Context, opts NewMonitorOptions) *Monitor {
+	return &Monitor{
+		ctx:               ctx,
+		log:               logger.Pipeline(opts.ID, "quality").With(logger.DisplayKey, opts.GetDisplayId()),
+		detector:          NewDetector(opts.Thresholds),
+		NewMonitorOptions: &opts,
+	}
+}
+
+// Active returns the checks that are currently tripped, it's safe to call on a nil Monitor.
+func (m *Monitor) Active() []Kind {
+	if m == nil {
+		return nil
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.detector.Active()
+}
+
+// Start starts the analysis process, it runs until the context is done.
+func (m *Monitor) Start() error {
+	cmd := exec.CommandContext(m.ctx, "ffmpeg", Args(
+		m.GetDisplayId(),
+		m.GetWidth(),
+		m.GetHeight(),
+		m.GetPulseMonitorId(),
+		m.Thresholds,
+	)...)
+
+	stderr, err := cmd.StderrPipe()
+
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start FFmpeg: %v", err)
+	}
+
+	m.log.Info("Quality monitor started", "pid", cmd.Process.Pid,
+		"silence_after", m.Thresholds.SilenceAfter,
+		"black_after", m.Thresholds.BlackAfter,
+		"freeze_after", m.Thresholds.FreezeAfter,
+	)
+
+	m.Wg.Add(1)
+	go func() {
+		defer m.Wg.Done()
+
+		scanner := bufio.NewScanner(stderr)
+
+		for scanner.Scan() {
+			m.feed(scanner.Text())
+		}
+
+		if err := cmd.Wait(); err != nil && m.ctx.Err() == nil {
+			m.log.Warn("Quality monitor exited", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// feed passes a line of analysis output to the detector, reporting the transitions.
+func (m *Monitor) feed(line string) {
+	m.mtx.Lock()
+	t, ok := m.detector.Feed(line)
+	m.mtx.Unlock()
+
+	if !ok {
+		return
+	}
+
+	eventType := events.QualityRecovered
+
+	if t.Active {
+		m.log.Warn("Recording quality check tripped", "kind", t.Kind, "since", t.Offset)
+		eventType = events.QualityAlert
+		metrics.QualityAlerts.WithLabelValues(string(t.Kind)).Inc()
+	} else {
+		m.log.Info("Recording quality check cleared", "kind", t.Kind)
+	}
+
+	events.GetBus(&m.ctx).Emit(m.ID, eventType, map[string]any{
+		"kind":      t.Kind,
+		"offset_ms": t.Offset.Milliseconds(),
+		"after_ms":  m.threshold(t.Kind).Milliseconds(),
+	})
+
+	if t.Active && m.OnAlert != nil {
+		m.OnAlert(t)
+	}
+}
+
+func (m *Monitor) threshold(kind Kind) time.Duration {
+	switch kind {
+	case KindSilence:
+		return m.Thresholds.SilenceAfter
+	case KindBlack:
+		return m.Thresholds.BlackAfter
+	case KindFreeze:
+		return m.Thresholds.FreezeAfter
+	}
+
+	return 0
+}
@@ -0,0 +1,37 @@
+package quality_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/OmGuptaIND/quality"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorCountsAlerts(t *testing.T) {
+	silence := testutil.ToFloat64(metrics.QualityAlerts.WithLabelValues(string(quality.KindSilence)))
+
+	var alerts []quality.Transition
+
+	m := quality.NewMonitor(context.Background(), quality.NewMonitorOptions{
+		ID:         "pipeline_1",
+		Thresholds: quality.Thresholds{SilenceAfter: 5 * time.Second},
+		OnAlert:    func(t quality.Transition) { alerts = append(alerts, t) },
+		Display:    display.NewDisplay(display.DisplayOptions{ID: "pipeline_1"}),
+	})
+
+	m.Feed("[silencedetect @ 0x5581] silence_start: 12.5")
+
+	assert.Equal(t, silence+1, testutil.ToFloat64(metrics.QualityAlerts.WithLabelValues(string(quality.KindSilence))))
+	assert.Len(t, alerts, 1)
+
+	// Clearing a check isn't an alert.
+	m.Feed("[silencedetect @ 0x5581] silence_end: 20.1 | silence_duration: 7.6")
+
+	assert.Equal(t, silence+1, testutil.ToFloat64(metrics.QualityAlerts.WithLabelValues(string(quality.KindSilence))))
+	assert.Len(t, alerts, 1)
+}
@@ -0,0 +1,199 @@
+package quality
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindSilence Kind = "silence"
+	KindBlack   Kind = "black"
+	KindFreeze  Kind = "freeze"
+)
+
+// Thresholds is how long the recording may stay silent, black or frozen before it is reported, 0 turns a check off.
+type Thresholds struct {
+	SilenceAfter time.Duration `json:"silence_after"`
+	BlackAfter   time.Duration `json:"black_after"`
+	FreezeAfter  time.Duration `json:"freeze_after"`
+
+	// Fail stops the pipeline as soon as one of the checks trips.
+	Fail bool `json:"fail"`
+}
+
+// Enabled reports whether any check is on.
+func (t Thresholds) Enabled() bool {
+	return t.SilenceAfter > 0 || t.BlackAfter > 0 || t.FreezeAfter > 0
+}
+
+func (t Thresholds) video() bool {
+	return t.BlackAfter > 0 || t.FreezeAfter > 0
+}
+
+// Transition is a check tripping or clearing, Offset is the analysis time it happened at.
+type Transition struct {
+	Kind   Kind
+	Active bool
+	Offset time.Duration
+}
+
+// framerate is the rate the display is sampled at, blackframe reports every black frame.
+const framerate = 1
+
+// Args returns the ffmpeg arguments analysing the display and the Pulse monitor, the output is thrown away.
+func Args(display string, width int, height int, monitor string, t Thresholds) []string {
+	args := []string{"-nostdin", "-loglevel", "info"}
+
+	if t.video() {
+		args = append(args,
+			"-thread_queue_size", "64",
+			"-framerate", strconv.Itoa(framerate),
+			"-video_size", fmt.Sprintf("%dx%d", width, height),
+			"-f", "x11grab",
+			"-i", display,
+		)
+	}
+
+	if t.SilenceAfter > 0 {
+		args = append(args,
+			"-thread_queue_size", "64",
+			"-f", "pulse",
+			"-i", monitor,
+		)
+	}
+
+	if t.video() {
+		filters := []string{"scale=320:-2"}
+
+		if t.BlackAfter > 0 {
+			filters = append(filters, "blackframe=amount=98:threshold=32", "blackdetect=d=0.5:pix_th=0.10")
+		}
+
+		if t.FreezeAfter > 0 {
+			filters = append(filters, fmt.Sprintf("freezedetect=n=-60dB:d=%s", seconds(t.FreezeAfter)))
+		}
+
+		args = append(args, "-vf", strings.Join(filters, ","))
+	}
+
+	if t.SilenceAfter > 0 {
+		args = append(args, "-af", fmt.Sprintf("silencedetect=n=-50dB:d=%s", seconds(t.SilenceAfter)))
+	}
+
+	return append(args, "-f", "null", "-")
+}
+
+// Detector turns the filter output of the analysis into Transitions.
+// Silence and freezes are timed by the filters themselves, black frames are timed here.
+type Detector struct {
+	Thresholds
+
+	active     map[Kind]bool
+	blackSince float64
+	blackLast  float64
+	inBlack    bool
+}
+
+// NewDetector creates a new Detector.
+func NewDetector(t Thresholds) *Detector {
+	return &Detector{
+		Thresholds: t,
+		active:     make(map[Kind]bool),
+	}
+}
+
+// Active returns the checks that are currently tripped.
+func (d *Detector) Active() []Kind {
+	kinds := make([]Kind, 0, len(d.active))
+
+	for _, kind := range []Kind{KindSilence, KindBlack, KindFreeze} {
+		if d.active[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	return kinds
+}
+
+// Feed parses a line of ffmpeg output, returning the transition it causes if any.
+func (d *Detector) Feed(line string) (Transition, bool) {
+	switch {
+	case strings.Contains(line, "silence_start:"):
+		return d.set(KindSilence, true, field(line, "silence_start:"))
+	case strings.Contains(line, "silence_end:"):
+		return d.set(KindSilence, false, field(line, "silence_end:"))
+	case strings.Contains(line, "freeze_start:"):
+		return d.set(KindFreeze, true, field(line, "freeze_start:"))
+	case strings.Contains(line, "freeze_end:"):
+		return d.set(KindFreeze, false, field(line, "freeze_end:"))
+	case strings.Contains(line, "black_end:"):
+		d.inBlack = false
+		return d.set(KindBlack, false, field(line, "black_end:"))
+	case strings.Contains(line, "pblack:"):
+		return d.blackFrame(field(line, " t:"))
+	}
+
+	return Transition{}, false
+}
+
+// blackFrame extends the current run of black frames, starting a new one after a gap.
+func (d *Detector) blackFrame(t float64) (Transition, bool) {
+	if !d.inBlack || t-d.blackLast > 2.0/framerate {
+		d.inBlack = true
+		d.blackSince = t
+	}
+
+	d.blackLast = t
+
+	if d.BlackAfter > 0 && t-d.blackSince >= d.BlackAfter.Seconds() {
+		return d.set(KindBlack, true, d.blackSince)
+	}
+
+	return Transition{}, false
+}
+
+// set records the state of a check, only changes are transitions.
+func (d *Detector) set(kind Kind, active bool, offset float64) (Transition, bool) {
+	if d.active[kind] == active {
+		return Transition{}, false
+	}
+
+	if active {
+		d.active[kind] = true
+	} else {
+		delete(d.active, kind)
+	}
+
+	return Transition{
+		Kind:   kind,
+		Active: active,
+		Offset: time.Duration(offset * float64(time.Second)),
+	}, true
+}
+
+// field returns the number following key in line.
+func field(line string, key string) float64 {
+	_, rest, ok := strings.Cut(line, key)
+
+	if !ok {
+		return 0
+	}
+
+	fields := strings.Fields(rest)
+
+	if len(fields) == 0 {
+		return 0
+	}
+
+	value, _ := strconv.ParseFloat(fields[0], 64)
+
+	return value
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
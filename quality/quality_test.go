@@ -0,0 +1,82 @@
+package quality_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/quality"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgsOnlyIncludeEnabledChecks(t *testing.T) {
+	args := strings.Join(quality.Args(":99", 1280, 720, "pipeline_1.monitor", quality.Thresholds{
+		SilenceAfter: 30 * time.Second,
+	}), " ")
+
+	assert.Contains(t, args, "-f pulse -i pipeline_1.monitor")
+	assert.Contains(t, args, "silencedetect=n=-50dB:d=30")
+	assert.NotContains(t, args, "x11grab")
+
+	args = strings.Join(quality.Args(":99", 1280, 720, "pipeline_1.monitor", quality.Thresholds{
+		BlackAfter:  10 * time.Second,
+		FreezeAfter: 1500 * time.Millisecond,
+	}), " ")
+
+	assert.Contains(t, args, "-video_size 1280x720 -f x11grab -i :99")
+	assert.Contains(t, args, "blackframe")
+	assert.Contains(t, args, "freezedetect=n=-60dB:d=1.5")
+	assert.NotContains(t, args, "pulse")
+	assert.True(t, strings.HasSuffix(args, "-f null -"))
+}
+
+func TestDetectorSilenceAndFreeze(t *testing.T) {
+	d := quality.NewDetector(quality.Thresholds{SilenceAfter: 5 * time.Second, FreezeAfter: 5 * time.Second})
+
+	tr, ok := d.Feed("[silencedetect @ 0x5581] silence_start: 12.5")
+	assert.True(t, ok)
+	assert.Equal(t, quality.Transition{Kind: quality.KindSilence, Active: true, Offset: 12500 * time.Millisecond}, tr)
+
+	_, ok = d.Feed("[freezedetect @ 0x5590] lavfi.freezedetect.freeze_start: 3")
+	assert.True(t, ok)
+	assert.Equal(t, []quality.Kind{quality.KindSilence, quality.KindFreeze}, d.Active())
+
+	_, ok = d.Feed("[freezedetect @ 0x5590] lavfi.freezedetect.freeze_duration: 9")
+	assert.False(t, ok)
+
+	tr, ok = d.Feed("[silencedetect @ 0x5581] silence_end: 20.1 | silence_duration: 7.6")
+	assert.True(t, ok)
+	assert.False(t, tr.Active)
+	assert.Equal(t, []quality.Kind{quality.KindFreeze}, d.Active())
+}
+
+func TestDetectorTimesBlackFrames(t *testing.T) {
+	d := quality.NewDetector(quality.Thresholds{BlackAfter: 3 * time.Second})
+
+	black := func(at string) (quality.Transition, bool) {
+		return d.Feed("[Parsed_blackframe_1 @ 0x55] frame:1 pblack:100 pts:1 t:" + at + " type:P last_keyframe:0")
+	}
+
+	for _, at := range []string{"1.000000", "2.000000", "3.000000"} {
+		_, ok := black(at)
+		assert.False(t, ok)
+	}
+
+	// A gap starts a new run.
+	_, ok := black("10.000000")
+	assert.False(t, ok)
+
+	for _, at := range []string{"11.000000", "12.000000"} {
+		_, ok := black(at)
+		assert.False(t, ok)
+	}
+
+	tr, ok := black("13.000000")
+	assert.True(t, ok)
+	assert.Equal(t, quality.Transition{Kind: quality.KindBlack, Active: true, Offset: 10 * time.Second}, tr)
+
+	tr, ok = d.Feed("[blackdetect @ 0x56] black_start:10 black_end:14 black_duration:4")
+	assert.True(t, ok)
+	assert.False(t, tr.Active)
+	assert.Empty(t, d.Active())
+}
@@ -0,0 +1,390 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/executor"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/pkg"
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusStarting  Status = "starting"
+	StatusStarted   Status = "started"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// terminal reports whether an entry in this status is done with.
+func (s Status) terminal() bool {
+	return s == StatusStarted || s == StatusFailed || s == StatusCancelled
+}
+
+// maxFinished is the number of started, failed or cancelled entries kept for querying.
+const maxFinished = 1000
+
+// LaunchFunc starts a pipeline from a queued payload and returns the pipeline ID.
+type LaunchFunc func(payload json.RawMessage) (string, error)
+
+type Entry struct {
+	ID         string          `json:"id"`
+	Priority   int             `json:"priority"`
+	Seq        uint64          `json:"seq"`
+	Status     Status          `json:"status"`
+	Payload    json.RawMessage `json:"payload"`
+	PipelineID string          `json:"pipeline_id,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+type QueueOptions struct {
+	// Path is the file the queue is persisted to.
+	Path string
+	// Reserve takes a pipeline slot for an entry about to start, false when the node is at capacity.
+	// The launcher owns the slot from then on. Nil means unlimited.
+	Reserve func() bool
+	// Paused reports whether dispatching is on hold, e.g. while the node drains.
+	Paused       func() bool
+	Executor     *executor.WorkerExecutor
+	PollInterval time.Duration
+}
+
+type Queue struct {
+	mu       sync.Mutex
+	entries  map[string]*Entry
+	seq      uint64
+	starting int
+
+	launch LaunchFunc
+	wake   chan struct{}
+	log    *slog.Logger
+
+	*QueueOptions
+}
+
+// GetQueue retrieves the start queue from the context.
+func GetQueue(ctx *context.Context) *Queue {
+	q, _ := (*ctx).Value(config.QueueKey).(*Queue)
+
+	return q
+}
+
+// NewQueue creates a new Queue, restoring any entries persisted at opts.Path.
+func NewQueue(opts *QueueOptions) (*Queue, error) {
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+
+	q := &Queue{
+		entries:      make(map[string]*Entry),
+		wake:         make(chan struct{}, 1),
+		log:          logger.Component("queue"),
+		QueueOptions: opts,
+	}
+
+	var entries []*Entry
+
+	if err := pkg.ReadJSONFile(opts.Path, &entries); err != nil {
+		return nil, fmt.Errorf("failed to load queue: %w", err)
+	}
+
+	for _, e := range entries {
+		// A start that was in flight when the node went down never completed, so try again.
+		if e.Status == StatusStarting {
+			e.Status = StatusPending
+		}
+
+		if e.Seq > q.seq {
+			q.seq = e.Seq
+		}
+
+		q.entries[e.ID] = e
+	}
+
+	q.log.Info("Queue loaded", "entries", len(q.entries), "path", opts.Path)
+
+	return q, nil
+}
+
+// SetLauncher sets the function used to start queued pipelines.
+func (q *Queue) SetLauncher(launch LaunchFunc) {
+	q.mu.Lock()
+	q.launch = launch
+	q.mu.Unlock()
+
+	q.Notify()
+}
+
+// Push adds a new pending entry to the queue, higher priorities are started first.
+func (q *Queue) Push(payload json.RawMessage, priority int) (*Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	now := time.Now().UTC()
+
+	e := &Entry{
+		ID:        fmt.Sprintf("queue_%s", uuid.New().String()),
+		Priority:  priority,
+		Seq:       q.seq,
+		Status:    StatusPending,
+		Payload:   payload,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.entries[e.ID] = e
+
+	if err := q.persistLocked(); err != nil {
+		delete(q.entries, e.ID)
+		return nil, err
+	}
+
+	q.Notify()
+
+	entry := *e
+
+	return &entry, nil
+}
+
+// Get returns a copy of the entry with the given ID.
+func (q *Queue) Get(id string) (*Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.entries[id]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := *e
+
+	return &entry, true
+}
+
+// Cancel cancels a pending entry, entries that already started can't be cancelled.
+func (q *Queue) Cancel(id string) (*Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.entries[id]
+
+	if !ok {
+		return nil, fmt.Errorf("queue entry not found: %s", id)
+	}
+
+	if e.Status != StatusPending {
+		return nil, fmt.Errorf("queue entry is %s, only pending entries can be cancelled", e.Status)
+	}
+
+	q.setStatusLocked(e, StatusCancelled)
+
+	entry := *e
+
+	return &entry, nil
+}
+
+// Pending returns the number of entries waiting to be started.
+func (q *Queue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+
+	for _, e := range q.entries {
+		if e.Status == StatusPending {
+			count++
+		}
+	}
+
+	return count
+}
+
+// InFlight returns the number of entries currently being started.
+func (q *Queue) InFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.starting
+}
+
+// Notify wakes up the dispatcher, call it whenever a pipeline slot frees up.
+func (q *Queue) Notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run drains the queue until the context is done.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			q.log.Info("Queue dispatcher stopped")
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch hands pending entries to the executor while there are free slots.
+func (q *Queue) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		q.mu.Lock()
+
+		if q.launch == nil || q.paused() {
+			q.mu.Unlock()
+			return
+		}
+
+		e := q.nextLocked()
+
+		if e == nil || !q.reserve() {
+			q.mu.Unlock()
+			return
+		}
+
+		q.starting++
+		q.setStatusLocked(e, StatusStarting)
+
+		launch := q.launch
+		id, payload := e.ID, e.Payload
+		q.mu.Unlock()
+
+		q.Executor.Enqueue(executor.Job{
+			Id:  id,
+			Ctx: ctx,
+			// A launch is never retried by the executor, a failed start is final and has already emitted pipeline.failed.
+			// The outcome is recorded here rather than in the callbacks, which are skipped once ctx is done.
+			JobFunc: func() error {
+				pipelineID, err := launch(payload)
+				q.finish(id, pipelineID, err)
+				return nil
+			},
+		})
+	}
+}
+
+// finish records the outcome of a start attempt.
+func (q *Queue) finish(id string, pipelineID string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.starting--
+
+	e, ok := q.entries[id]
+
+	if !ok {
+		return
+	}
+
+	if err != nil {
+		q.log.Error("Queued pipeline failed to start", "queue_id", id, "error", err)
+		e.Error = err.Error()
+		q.setStatusLocked(e, StatusFailed)
+		return
+	}
+
+	q.log.Info("Queued pipeline started", "queue_id", id, logger.PipelineKey, pipelineID)
+	e.PipelineID = pipelineID
+	q.setStatusLocked(e, StatusStarted)
+}
+
+// paused reports whether dispatching is on hold.
+func (q *Queue) paused() bool {
+	return q.Paused != nil && q.Paused()
+}
+
+// reserve takes a pipeline slot for the next entry.
+func (q *Queue) reserve() bool {
+	return q.Reserve == nil || q.Reserve()
+}
+
+// nextLocked returns the pending entry with the highest priority, oldest first.
+func (q *Queue) nextLocked() *Entry {
+	var next *Entry
+
+	for _, e := range q.entries {
+		if e.Status != StatusPending {
+			continue
+		}
+
+		if next == nil || e.Priority > next.Priority || (e.Priority == next.Priority && e.Seq < next.Seq) {
+			next = e
+		}
+	}
+
+	return next
+}
+
+// setStatusLocked updates the status of an entry and persists the queue.
+func (q *Queue) setStatusLocked(e *Entry, status Status) {
+	e.Status = status
+	e.UpdatedAt = time.Now().UTC()
+
+	if status.terminal() {
+		q.pruneLocked()
+	}
+
+	if err := q.persistLocked(); err != nil {
+		q.log.Error("Failed to persist queue", "error", err)
+	}
+}
+
+// pruneLocked drops the oldest started, failed or cancelled entries beyond maxFinished.
+func (q *Queue) pruneLocked() {
+	finished := make([]*Entry, 0)
+
+	for _, e := range q.entries {
+		if e.Status.terminal() {
+			finished = append(finished, e)
+		}
+	}
+
+	if len(finished) <= maxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].UpdatedAt.Before(finished[j].UpdatedAt)
+	})
+
+	for _, e := range finished[:len(finished)-maxFinished] {
+		delete(q.entries, e.ID)
+	}
+}
+
+// persistLocked writes the queue to disk.
+func (q *Queue) persistLocked() error {
+	if q.Path == "" {
+		return nil
+	}
+
+	entries := make([]*Entry, 0, len(q.entries))
+
+	for _, e := range q.entries {
+		entries = append(entries, e)
+	}
+
+	return pkg.WriteJSONFile(q.Path, entries)
+}
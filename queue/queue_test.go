@@ -0,0 +1,242 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/executor"
+	"github.com/OmGuptaIND/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQueue(t *testing.T, ctx context.Context, path string, reserve func() bool) *queue.Queue {
+	jobExecutor := executor.NewWorkerExecutor(ctx, &executor.WorkerExecutorOptions{
+		WorkerCount: 1,
+	})
+	jobExecutor.Start()
+
+	q, err := queue.NewQueue(&queue.QueueOptions{
+		Path:         path,
+		Reserve:      reserve,
+		Executor:     jobExecutor,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	assert.Nil(t, err)
+
+	return q
+}
+
+func TestQueueStartsByPriorityWithinCapacity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mtx sync.Mutex
+	running := 0
+	launched := make([]string, 0)
+
+	// A single slot, taken as the entry is dispatched.
+	q := newTestQueue(t, ctx, filepath.Join(t.TempDir(), "queue.json"), func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if running >= 1 {
+			return false
+		}
+
+		running++
+
+		return true
+	})
+
+	low, err := q.Push(json.RawMessage(`"low"`), 0)
+	assert.Nil(t, err)
+
+	high, err := q.Push(json.RawMessage(`"high"`), 10)
+	assert.Nil(t, err)
+
+	q.SetLauncher(func(payload json.RawMessage) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		launched = append(launched, string(payload))
+
+		return "pipeline_" + string(payload), nil
+	})
+
+	go q.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		e, _ := q.Get(high.ID)
+		return e.Status == queue.StatusStarted
+	}, time.Second, 10*time.Millisecond)
+
+	// Only one slot, so the low priority entry must keep waiting.
+	time.Sleep(50 * time.Millisecond)
+	e, _ := q.Get(low.ID)
+	assert.Equal(t, queue.StatusPending, e.Status)
+
+	mtx.Lock()
+	running = 0
+	mtx.Unlock()
+	q.Notify()
+
+	assert.Eventually(t, func() bool {
+		e, _ := q.Get(low.ID)
+		return e.Status == queue.StatusStarted
+	}, time.Second, 10*time.Millisecond)
+
+	e, _ = q.Get(low.ID)
+	assert.Equal(t, `pipeline_"low"`, e.PipelineID)
+	assert.Equal(t, []string{`"high"`, `"low"`}, launched)
+}
+
+func TestQueueCancelAndPersist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q := newTestQueue(t, ctx, path, nil)
+
+	first, err := q.Push(json.RawMessage(`{}`), 0)
+	assert.Nil(t, err)
+
+	second, err := q.Push(json.RawMessage(`{}`), 0)
+	assert.Nil(t, err)
+
+	cancelled, err := q.Cancel(first.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, queue.StatusCancelled, cancelled.Status)
+
+	_, err = q.Cancel(first.ID)
+	assert.NotNil(t, err)
+
+	restored := newTestQueue(t, ctx, path, nil)
+
+	e, ok := restored.Get(first.ID)
+	assert.True(t, ok)
+	assert.Equal(t, queue.StatusCancelled, e.Status)
+
+	e, ok = restored.Get(second.ID)
+	assert.True(t, ok)
+	assert.Equal(t, queue.StatusPending, e.Status)
+	assert.Equal(t, 1, restored.Pending())
+}
+
+func TestQueueLaunchesOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := newTestQueue(t, ctx, "", nil)
+
+	var mtx sync.Mutex
+	attempts := 0
+
+	entry, err := q.Push(json.RawMessage(`{}`), 0)
+	assert.Nil(t, err)
+
+	q.SetLauncher(func(payload json.RawMessage) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		attempts++
+
+		return "", assert.AnError
+	})
+
+	go q.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		e, _ := q.Get(entry.ID)
+		return e.Status == queue.StatusFailed
+	}, time.Second, 10*time.Millisecond)
+
+	// A failed start is final, it must not be launched again.
+	time.Sleep(50 * time.Millisecond)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	assert.Equal(t, 1, attempts)
+}
+
+func TestQueuePrunesFinished(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := newTestQueue(t, ctx, "", nil)
+
+	ids := make([]string, 0)
+
+	for range 1010 {
+		e, err := q.Push(json.RawMessage(`{}`), 0)
+		assert.Nil(t, err)
+
+		_, err = q.Cancel(e.ID)
+		assert.Nil(t, err)
+
+		ids = append(ids, e.ID)
+	}
+
+	pending, err := q.Push(json.RawMessage(`{}`), 0)
+	assert.Nil(t, err)
+
+	// The oldest finished entries go first, pending ones are always kept.
+	_, ok := q.Get(ids[0])
+	assert.False(t, ok)
+
+	_, ok = q.Get(ids[len(ids)-1])
+	assert.True(t, ok)
+
+	_, ok = q.Get(pending.ID)
+	assert.True(t, ok)
+}
+
+func TestQueueRecordsLaunchesFinishingAfterShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q := newTestQueue(t, ctx, path, nil)
+
+	entry, err := q.Push(json.RawMessage(`{}`), 0)
+	assert.Nil(t, err)
+
+	launched := make(chan struct{})
+
+	// The node shuts down while the pipeline is starting.
+	q.SetLauncher(func(payload json.RawMessage) (string, error) {
+		defer close(launched)
+
+		cancel()
+
+		return "pipeline_1", nil
+	})
+
+	go q.Run(ctx)
+
+	select {
+	case <-launched:
+	case <-time.After(time.Second):
+		t.Fatal("entry never launched")
+	}
+
+	assert.Eventually(t, func() bool {
+		return q.InFlight() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	e, _ := q.Get(entry.ID)
+	assert.Equal(t, queue.StatusStarted, e.Status)
+	assert.Equal(t, "pipeline_1", e.PipelineID)
+
+	// The outcome made it to disk, the entry isn't launched again on restart.
+	restored := newTestQueue(t, context.Background(), path, nil)
+
+	e, ok := restored.Get(entry.ID)
+	assert.True(t, ok)
+	assert.Equal(t, queue.StatusStarted, e.Status)
+}
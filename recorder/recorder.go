@@ -5,19 +5,26 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"sync"
 	"time"
 
 	"github.com/OmGuptaIND/display"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/hls"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
+	"github.com/OmGuptaIND/progress"
 )
 
 type NewRecorderOptions struct {
 	ID             string
 	ShowFfmpegLogs bool
 	Wg             *sync.WaitGroup
+	// HLS writes the recording as an HLS playlist and segments into HLS.Dir, instead of an MP4 to stdout.
+	HLS *hls.Options
 	*display.Display
 }
 
@@ -26,10 +33,15 @@ type Recorder struct {
 	mtx       *sync.Mutex
 	recordCmd *exec.Cmd
 	stdout    *io.ReadCloser
+	log       *slog.Logger
 
 	CloseHook func() error
 
-	done chan error
+	// Progress tracks the encoder statistics of the recording process.
+	Progress *progress.Monitor
+
+	done   chan error
+	exited chan struct{}
 
 	*NewRecorderOptions
 }
@@ -40,9 +52,24 @@ func NewRecorder(ctx context.Context, opts NewRecorderOptions) (*Recorder, error
 		ctx:                ctx,
 		mtx:                &sync.Mutex{},
 		done:               make(chan error, 1),
+		exited:             make(chan struct{}),
+		log:                logger.Pipeline(opts.ID, "recorder").With(logger.DisplayKey, opts.GetDisplayId()),
 		NewRecorderOptions: &opts,
 	}
 
+	recorder.Progress = progress.NewMonitor(&progress.MonitorOptions{
+		SlowAfter:    progress.DefaultSlowAfter,
+		OnSlowChange: recorder.onSlowChange,
+		OnUpdate: func(stats progress.Stats) {
+			if stats.Ended {
+				metrics.EncodeSpeed.DeleteLabelValues(opts.ID, "recorder")
+				return
+			}
+
+			metrics.EncodeSpeed.WithLabelValues(opts.ID, "recorder").Set(stats.Speed)
+		},
+	})
+
 	return recorder, nil
 }
 
@@ -56,10 +83,13 @@ func (r *Recorder) Done() <-chan error {
 	return r.done
 }
 
+// Exited returns a channel that is closed once the recording process has exited.
+func (r *Recorder) Exited() <-chan struct{} {
+	return r.exited
+}
+
 // GetRecorderStdout returns the stdout of the recording process.
 func (r *Recorder) GetReader() *bufio.Reader {
-	log.Println("Getting Recorder stdout...", r.stdout)
-
 	if r.stdout == nil {
 		return nil
 	}
@@ -78,13 +108,14 @@ func (r *Recorder) StartRecording() error {
 		return fmt.Errorf("recording already in progress")
 	}
 
-	log.Println("Starting Recorder process...")
+	r.log.Info("Starting Recorder process")
 	r.Wg.Add(1)
 	go r.handleContextCancel()
 
-	cmd := exec.Command("ffmpeg",
+	args := []string{
 		"-nostdin",
 		"-loglevel", "info",
+		"-progress", "pipe:3",
 		"-thread_queue_size", "512",
 		"-video_size", fmt.Sprintf("%dx%d", r.GetWidth(), r.GetHeight()),
 		"-f", "x11grab",
@@ -98,44 +129,71 @@ func (r *Recorder) StartRecording() error {
 		"-c:a", "aac",
 		"-b:a", "128k",
 		"-async", "1",
-		"-f", "mp4",
-		"-movflags", "frag_keyframe+empty_moov+default_base_moof",
-		"-bufsize", "2M",
-		"-flush_packets", "1",
-		"-y",
-		"pipe:1",
-	)
+	}
+
+	if r.HLS != nil {
+		args = append(args, r.HLS.OutputArgs()...)
+	} else {
+		args = append(args,
+			"-f", "mp4",
+			"-movflags", "frag_keyframe+empty_moov+default_base_moof",
+			"-bufsize", "2M",
+			"-flush_packets", "1",
+			"-y",
+			"pipe:1",
+		)
+	}
 
-	stdout, err := cmd.StdoutPipe()
+	cmd := exec.Command("ffmpeg", args...)
 
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %v", err)
+	if r.HLS == nil {
+		stdout, err := cmd.StdoutPipe()
+
+		if err != nil {
+			return fmt.Errorf("failed to create stdout pipe: %v", err)
+		}
+
+		r.stdout = &stdout
 	}
 
-	log.Println("Recording Command:", stdout)
+	if err := r.captureFfmpegLogs(cmd); err != nil {
+		return fmt.Errorf("failed to capture ffmpeg logs: %v", err)
+	}
 
-	r.stdout = &stdout
+	closeProgress, err := r.Progress.Attach(cmd)
 
-	if err := r.showFfmpegLogs(); err != nil {
-		return fmt.Errorf("failed to show ffmpeg logs: %v", err)
+	if err != nil {
+		return fmt.Errorf("failed to create progress pipe: %v", err)
 	}
 
 	r.recordCmd = cmd
 
-	if err := cmd.Start(); err != nil {
+	err = cmd.Start()
+	closeProgress()
+
+	if err != nil {
 		return fmt.Errorf("failed to start FFmpeg: %v", err)
 	}
 
 	r.Wg.Add(1)
 	go func() {
 		defer r.Wg.Done()
+		defer close(r.exited)
 
 		if err := cmd.Wait(); err != nil {
+			// An exit before the context is done means ffmpeg died under us.
+			if r.ctx.Err() == nil {
+				events.GetBus(&r.ctx).Emit(r.ID, events.PipelineError, map[string]any{
+					"component": "recorder",
+					"error":     err.Error(),
+				})
+			}
+
 			r.done <- err
 		}
 	}()
 
-	log.Println("Recorder process started successfully")
+	r.log.Info("Recorder process started successfully", "pid", cmd.Process.Pid)
 
 	return nil
 }
@@ -143,30 +201,30 @@ func (r *Recorder) StartRecording() error {
 // Close sends an interrupt signal to the recording process and waits for it to finish.
 func (r *Recorder) Close() error {
 	if r.recordCmd == nil {
-		log.Println("Recording process is not running")
+		r.log.Info("Recording process is not running")
 		return nil
 	}
 
 	if r.ctx.Err() != nil {
-		log.Println("Context is already cancelled, no need to stop Recorder")
+		r.log.Info("Context is already cancelled, no need to stop Recorder")
 		return nil
 	}
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	log.Println("Stopping Recorder process...")
+	r.log.Info("Stopping Recorder process")
 
 	if r.recordCmd == nil || r.recordCmd.Process == nil {
-		log.Println("Recording FFmpeg process is not running")
+		r.log.Info("Recording FFmpeg process is not running")
 		return nil
 	}
 
 	defer func() {
 		if r.CloseHook != nil {
-			log.Println("Cleaning Up Recorder CloseHook...")
+			r.log.Info("Cleaning Up Recorder CloseHook")
 			if err := r.CloseHook(); err != nil {
-				log.Printf("CloseHook failed: %v", err)
+				r.log.Error("CloseHook failed", "error", err)
 			}
 		}
 	}()
@@ -182,18 +240,18 @@ func (r *Recorder) Close() error {
 		if err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				if exitErr.ExitCode() != 255 || exitErr.ExitCode() != -1 {
-					log.Printf("FFmpeg process exited with status: %d", exitErr.ExitCode())
+					r.log.Info("FFmpeg process exited", "status", exitErr.ExitCode())
 				}
 			}
 		}
 	case <-timeout:
-		log.Println("Recording process did not stop in time, killing it")
+		r.log.Warn("Recording process did not stop in time, killing it")
 		if err := r.recordCmd.Process.Kill(); err != nil {
-			log.Printf("Failed to kill Recording process: %v", err)
+			r.log.Error("Failed to kill Recording process", "error", err)
 		}
 	}
 
-	log.Println("Recording process stopped")
+	r.log.Info("Recording process stopped")
 
 	r.recordCmd = nil
 	r.stdout = nil
@@ -202,41 +260,51 @@ func (r *Recorder) Close() error {
 	return nil
 }
 
+// onSlowChange reports the recording falling behind real time, or catching up again.
+func (r *Recorder) onSlowChange(stats progress.Stats) {
+	eventType := events.EncoderRecovered
+
+	if stats.Slow {
+		r.log.Warn("Recorder is encoding slower than real time, the node may be overloaded", "speed", stats.Speed)
+		eventType = events.EncoderSlow
+	}
+
+	events.GetBus(&r.ctx).Emit(r.ID, eventType, map[string]any{
+		"component":   "recorder",
+		"speed":       stats.Speed,
+		"fps":         stats.FPS,
+		"drop_frames": stats.DropFrames,
+	})
+}
+
 // handleContextCancel handles the context cancel signal.
 func (r *Recorder) handleContextCancel() {
 	defer r.Wg.Done()
 	<-r.ctx.Done()
-	log.Println("Context Done, Stopping Recorder")
+	r.log.Info("Context Done, Stopping Recorder")
 	r.Close()
 }
 
-// showFfmpegLogs shows the ffmpeg logs.
-func (r *Recorder) showFfmpegLogs() error {
-	if !r.ShowFfmpegLogs {
-		return nil
-	}
-
-	stderr, err := r.recordCmd.StderrPipe()
+// captureFfmpegLogs keeps the ffmpeg stderr with the pipeline logs, printing it too when ShowFfmpegLogs is set.
+func (r *Recorder) captureFfmpegLogs(cmd *exec.Cmd) error {
+	stderr, err := cmd.StderrPipe()
 
 	if err != nil {
-		log.Printf("Failed to create stderr pipe: %v", err)
+		r.log.Error("Failed to create stderr pipe", "error", err)
 		return err
 	}
 
-	r.Wg.Add(1)
-	copyOutput := func(writer io.Writer, reader io.Reader, name string) {
-		defer func() {
-			log.Println("Closing stderr pipe, Log Copy")
-			r.Wg.Done()
-		}()
-
-		_, err := io.Copy(writer, reader)
-		if err != nil && err != io.EOF {
-			log.Printf("Error copying %s: %v", name, err)
-		}
+	level := slog.LevelDebug
+
+	if r.ShowFfmpegLogs {
+		level = slog.LevelInfo
 	}
 
-	go copyOutput(os.Stderr, stderr, "stderr")
+	r.Wg.Add(1)
+	go func() {
+		defer r.Wg.Done()
+		logger.Capture(stderr, r.log.With(logger.ComponentKey, "ffmpeg"), level)
+	}()
 
 	return nil
 }
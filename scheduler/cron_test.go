@@ -0,0 +1,56 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, time.September, 2, 10, 30, 0, 0, time.UTC) // Monday
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, time.September, 2, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, time.September, 3, 9, 0, 0, 0, time.UTC)},
+		{"30 18 * * 3", time.Date(2024, time.September, 4, 18, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.September, 2, 11, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, time.September, 8, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted means either matches.
+		{"0 8 15 * 5", time.Date(2024, time.September, 6, 8, 0, 0, 0, time.UTC)},
+		// A day field starting with a star is unrestricted, so both must match: an odd day that's a Friday.
+		{"0 8 */2 * 5", time.Date(2024, time.September, 13, 8, 0, 0, 0, time.UTC)},
+		// The 1st of a month falling on an even day of the week.
+		{"0 8 1 * */2", time.Date(2024, time.October, 1, 8, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		cron, err := scheduler.ParseCron(c.expr)
+		assert.Nil(t, err, c.expr)
+		assert.Equal(t, c.want, cron.Next(from), c.expr)
+	}
+}
+
+func TestCronNextInTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	cron, err := scheduler.ParseCron("0 9 * * *")
+	assert.Nil(t, err)
+
+	// Across the DST change the run stays at 9am local time.
+	next := cron.Next(time.Date(2024, time.November, 2, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, time.November, 3, 14, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := scheduler.ParseCron(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
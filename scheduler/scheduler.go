@@ -0,0 +1,475 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/pkg"
+	"github.com/google/uuid"
+)
+
+// maxRuns is the number of past runs kept per schedule.
+const maxRuns = 100
+
+// LaunchFunc starts a pipeline for a scheduled payload, stopping it at stopAt, and returns the pipeline ID.
+type LaunchFunc func(payload json.RawMessage, stopAt time.Time) (string, error)
+
+// Run is a single launch of a schedule.
+type Run struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	LaunchedAt  time.Time `json:"launched_at"`
+	PipelineID  string    `json:"pipeline_id,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type Schedule struct {
+	ID string `json:"id"`
+	// StartAt is the time of the first run.
+	StartAt time.Time `json:"start_at"`
+	// Duration is how long each run records for, e.g. "1h".
+	Duration string `json:"duration"`
+	// Recurrence is an optional cron expression, runs happen at every match from StartAt on.
+	Recurrence string `json:"recurrence,omitempty"`
+	// Timezone is the IANA zone the recurrence is evaluated in, defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// Payload is the start request each run is launched with.
+	Payload json.RawMessage `json:"payload"`
+
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	Runs      []Run      `json:"runs"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// lastRunAt is when the last run fired was scheduled for, so editing the schedule doesn't launch it again.
+	lastRunAt time.Time
+}
+
+type SchedulerOptions struct {
+	// Path is the file the schedules are persisted to.
+	Path string
+	// Paused reports whether launches are on hold, e.g. while the node drains.
+	Paused func() bool
+}
+
+type Scheduler struct {
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+
+	launch LaunchFunc
+	wake   chan struct{}
+	log    *slog.Logger
+
+	*SchedulerOptions
+}
+
+// GetScheduler retrieves the scheduler from the context.
+func GetScheduler(ctx *context.Context) *Scheduler {
+	s, _ := (*ctx).Value(config.SchedulerKey).(*Scheduler)
+
+	return s
+}
+
+// NewScheduler creates a new Scheduler, restoring any schedules persisted at opts.Path.
+func NewScheduler(opts *SchedulerOptions) (*Scheduler, error) {
+	s := &Scheduler{
+		schedules:        make(map[string]*Schedule),
+		wake:             make(chan struct{}, 1),
+		log:              logger.Component("scheduler"),
+		SchedulerOptions: opts,
+	}
+
+	var schedules []*Schedule
+
+	if err := pkg.ReadJSONFile(opts.Path, &schedules); err != nil {
+		return nil, fmt.Errorf("failed to load schedules: %w", err)
+	}
+
+	for _, schedule := range schedules {
+		if n := len(schedule.Runs); n > 0 {
+			schedule.lastRunAt = schedule.Runs[n-1].ScheduledAt
+		}
+
+		s.schedules[schedule.ID] = schedule
+	}
+
+	s.log.Info("Scheduler loaded", "schedules", len(s.schedules), "path", opts.Path)
+
+	return s, nil
+}
+
+// Validate checks the schedule timing fields.
+func (s *Schedule) Validate() error {
+	if s.StartAt.IsZero() {
+		return fmt.Errorf("start_at is required")
+	}
+
+	d, err := time.ParseDuration(s.Duration)
+
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	if d <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	if s.Recurrence != "" {
+		if _, err := ParseCron(s.Recurrence); err != nil {
+			return fmt.Errorf("invalid recurrence: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// nextAfter returns the first run strictly after t, the zero time means there are no more runs.
+func (s *Schedule) nextAfter(t time.Time) time.Time {
+	if s.Recurrence == "" {
+		if s.StartAt.After(t) {
+			return s.StartAt
+		}
+
+		return time.Time{}
+	}
+
+	cron, err := ParseCron(s.Recurrence)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	// Runs start at the first match at or after StartAt.
+	if from := s.StartAt.Add(-time.Nanosecond); from.After(t) {
+		t = from
+	}
+
+	return cron.Next(t.In(loc))
+}
+
+// nextFrom returns the next run of a schedule created or edited at now.
+// A run that started less than its duration ago still gets launched, the same way a missed run does after a restart,
+// unless it was launched already.
+func (s *Schedule) nextFrom(now time.Time) time.Time {
+	from := now.Add(-s.duration())
+
+	if s.lastRunAt.After(from) {
+		from = s.lastRunAt
+	}
+
+	return s.nextAfter(from)
+}
+
+// duration returns the parsed run duration.
+func (s *Schedule) duration() time.Duration {
+	d, _ := time.ParseDuration(s.Duration)
+
+	return d
+}
+
+// SetLauncher sets the function used to launch scheduled pipelines.
+func (sc *Scheduler) SetLauncher(launch LaunchFunc) {
+	sc.mu.Lock()
+	sc.launch = launch
+	sc.mu.Unlock()
+
+	sc.notify()
+}
+
+// Create adds a new schedule.
+func (sc *Scheduler) Create(schedule *Schedule) (*Schedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	schedule.ID = fmt.Sprintf("schedule_%s", uuid.New().String())
+	schedule.Runs = make([]Run, 0)
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	schedule.NextRun = nil
+
+	schedule.lastRunAt = time.Time{}
+
+	if next := schedule.nextFrom(now); !next.IsZero() {
+		schedule.NextRun = &next
+	}
+
+	sc.schedules[schedule.ID] = schedule
+
+	if err := sc.persistLocked(); err != nil {
+		delete(sc.schedules, schedule.ID)
+		return nil, err
+	}
+
+	sc.notify()
+
+	return copySchedule(schedule), nil
+}
+
+// Update replaces the timing and payload of a schedule, keeping its past runs.
+func (sc *Scheduler) Update(id string, update *Schedule) (*Schedule, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	schedule, ok := sc.schedules[id]
+
+	if !ok {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+
+	now := time.Now().UTC()
+
+	schedule.StartAt = update.StartAt
+	schedule.Duration = update.Duration
+	schedule.Recurrence = update.Recurrence
+	schedule.Timezone = update.Timezone
+	schedule.Payload = update.Payload
+	schedule.UpdatedAt = now
+	schedule.NextRun = nil
+
+	if next := schedule.nextFrom(now); !next.IsZero() {
+		schedule.NextRun = &next
+	}
+
+	if err := sc.persistLocked(); err != nil {
+		return nil, err
+	}
+
+	sc.notify()
+
+	return copySchedule(schedule), nil
+}
+
+// Delete removes a schedule, pipelines it already launched keep running.
+func (sc *Scheduler) Delete(id string) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if _, ok := sc.schedules[id]; !ok {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+
+	delete(sc.schedules, id)
+
+	return sc.persistLocked()
+}
+
+// Get returns a copy of the schedule with the given ID.
+func (sc *Scheduler) Get(id string) (*Schedule, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	schedule, ok := sc.schedules[id]
+
+	if !ok {
+		return nil, false
+	}
+
+	return copySchedule(schedule), true
+}
+
+// List returns copies of all schedules, ordered by creation time.
+func (sc *Scheduler) List() []*Schedule {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	schedules := make([]*Schedule, 0, len(sc.schedules))
+
+	for _, schedule := range sc.schedules {
+		schedules = append(schedules, copySchedule(schedule))
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules
+}
+
+// Run launches schedules as they come due until the context is done.
+func (sc *Scheduler) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(sc.launchDue())
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			sc.log.Info("Scheduler stopped")
+			return
+		case <-sc.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// launchDue launches every due schedule and returns how long to wait for the next one.
+func (sc *Scheduler) launchDue() time.Duration {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	wait := time.Minute
+
+	if sc.launch == nil || (sc.Paused != nil && sc.Paused()) {
+		return wait
+	}
+
+	now := time.Now().UTC()
+	fired := false
+
+	for _, schedule := range sc.schedules {
+		if schedule.NextRun == nil {
+			continue
+		}
+
+		if runAt := *schedule.NextRun; !runAt.After(now) {
+			sc.fireLocked(schedule, runAt, now)
+			fired = true
+		}
+
+		if schedule.NextRun != nil {
+			if until := schedule.NextRun.Sub(now); until < wait {
+				wait = until
+			}
+		}
+	}
+
+	if fired {
+		if err := sc.persistLocked(); err != nil {
+			sc.log.Error("Failed to persist schedules", "error", err)
+		}
+	}
+
+	return max(wait, 0)
+}
+
+// fireLocked launches a due run and advances the schedule to its next run.
+// Runs missed while the node was down are still launched if they would be recording right now.
+func (sc *Scheduler) fireLocked(schedule *Schedule, runAt time.Time, now time.Time) {
+	duration := schedule.duration()
+	stopAt := runAt.Add(duration)
+
+	schedule.NextRun = nil
+	schedule.lastRunAt = runAt
+
+	// Skip straight past any other occurrences that ended while the node was down.
+	from := runAt
+
+	if missedUntil := now.Add(-duration); missedUntil.After(from) {
+		from = missedUntil
+	}
+
+	if next := schedule.nextAfter(from); !next.IsZero() {
+		schedule.NextRun = &next
+	}
+
+	if !now.Before(stopAt) {
+		sc.log.Warn("Schedule missed its run", "schedule_id", schedule.ID, "run_at", runAt)
+		sc.addRunLocked(schedule, Run{
+			ScheduledAt: runAt,
+			LaunchedAt:  now,
+			Error:       "missed, the node was not running at the scheduled time",
+		})
+		return
+	}
+
+	sc.log.Info("Launching schedule", "schedule_id", schedule.ID, "run_at", runAt, "stop_at", stopAt)
+
+	id, payload, launch := schedule.ID, schedule.Payload, sc.launch
+
+	go func() {
+		pipelineID, err := launch(payload, stopAt)
+
+		run := Run{
+			ScheduledAt: runAt,
+			LaunchedAt:  now,
+			PipelineID:  pipelineID,
+		}
+
+		if err != nil {
+			sc.log.Error("Schedule failed to launch", "schedule_id", id, "error", err)
+			run.Error = err.Error()
+		}
+
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+
+		if schedule, ok := sc.schedules[id]; ok {
+			sc.addRunLocked(schedule, run)
+
+			if err := sc.persistLocked(); err != nil {
+				sc.log.Error("Failed to persist schedules", "error", err)
+			}
+		}
+	}()
+}
+
+// addRunLocked records a run, keeping the most recent maxRuns.
+func (sc *Scheduler) addRunLocked(schedule *Schedule, run Run) {
+	schedule.Runs = append(schedule.Runs, run)
+
+	if len(schedule.Runs) > maxRuns {
+		schedule.Runs = schedule.Runs[len(schedule.Runs)-maxRuns:]
+	}
+}
+
+// notify wakes up the run loop.
+func (sc *Scheduler) notify() {
+	select {
+	case sc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// persistLocked writes the schedules to disk.
+func (sc *Scheduler) persistLocked() error {
+	if sc.Path == "" {
+		return nil
+	}
+
+	schedules := make([]*Schedule, 0, len(sc.schedules))
+
+	for _, schedule := range sc.schedules {
+		schedules = append(schedules, schedule)
+	}
+
+	return pkg.WriteJSONFile(sc.Path, schedules)
+}
+
+// copySchedule returns a copy of the schedule that is safe to hand out.
+func copySchedule(schedule *Schedule) *Schedule {
+	c := *schedule
+	c.Runs = append([]Run(nil), schedule.Runs...)
+
+	if schedule.NextRun != nil {
+		next := *schedule.NextRun
+		c.NextRun = &next
+	}
+
+	return &c
+}
@@ -0,0 +1,175 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/pkg"
+	"github.com/OmGuptaIND/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// launcher records the runs it is asked to launch.
+type launcher struct {
+	mtx    sync.Mutex
+	stopAt []time.Time
+}
+
+func (l *launcher) launch(payload json.RawMessage, stopAt time.Time) (string, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.stopAt = append(l.stopAt, stopAt)
+
+	return "pipeline_" + stopAt.Format(time.RFC3339), nil
+}
+
+func (l *launcher) launched() []time.Time {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return append([]time.Time(nil), l.stopAt...)
+}
+
+func newTestScheduler(t *testing.T, path string) *scheduler.Scheduler {
+	sc, err := scheduler.NewScheduler(&scheduler.SchedulerOptions{Path: path})
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return sc
+}
+
+func TestSchedulerFiresAndPersists(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	sc := newTestScheduler(t, path)
+
+	startAt := time.Now().UTC().Truncate(time.Second)
+
+	schedule, err := sc.Create(&scheduler.Schedule{StartAt: startAt, Duration: "1h", Payload: json.RawMessage(`{}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, startAt, *schedule.NextRun)
+
+	l := &launcher{}
+	sc.SetLauncher(l.launch)
+
+	go sc.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		s, _ := sc.Get(schedule.ID)
+		return len(s.Runs) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []time.Time{startAt.Add(time.Hour)}, l.launched())
+
+	// The runs and the end of a one off schedule survive a restart.
+	restored, ok := newTestScheduler(t, path).Get(schedule.ID)
+
+	assert.True(t, ok)
+	assert.Nil(t, restored.NextRun)
+
+	if assert.Len(t, restored.Runs, 1) {
+		assert.Equal(t, startAt, restored.Runs[0].ScheduledAt)
+		assert.NotEmpty(t, restored.Runs[0].PipelineID)
+		assert.Empty(t, restored.Runs[0].Error)
+	}
+}
+
+func TestSchedulerMissedRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now().UTC()
+	hour := now.Truncate(time.Hour)
+	missed := hour.Add(-3 * time.Hour)
+
+	// An hourly schedule, as persisted by a node that went down three hours ago.
+	path := filepath.Join(t.TempDir(), "schedules.json")
+
+	assert.NoError(t, pkg.WriteJSONFile(path, []*scheduler.Schedule{{
+		ID:         "schedule_1",
+		StartAt:    missed.Add(-24 * time.Hour),
+		Duration:   "1h",
+		Recurrence: "0 * * * *",
+		Payload:    json.RawMessage(`{}`),
+		NextRun:    &missed,
+		Runs:       []scheduler.Run{},
+		CreatedAt:  missed,
+		UpdatedAt:  missed,
+	}}))
+
+	sc := newTestScheduler(t, path)
+
+	l := &launcher{}
+	sc.SetLauncher(l.launch)
+
+	go sc.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		s, _ := sc.Get("schedule_1")
+		return len(s.Runs) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	s, _ := sc.Get("schedule_1")
+
+	// The run that ended while the node was down is reported, the one that should be recording right now is launched,
+	// and the runs in between are skipped.
+	assert.Equal(t, missed, s.Runs[0].ScheduledAt)
+	assert.NotEmpty(t, s.Runs[0].Error)
+	assert.Empty(t, s.Runs[0].PipelineID)
+
+	assert.Equal(t, hour, s.Runs[1].ScheduledAt)
+	assert.Empty(t, s.Runs[1].Error)
+	assert.Equal(t, []time.Time{hour.Add(time.Hour)}, l.launched())
+
+	assert.Equal(t, hour.Add(time.Hour), *s.NextRun)
+}
+
+func TestSchedulerUpdateKeepsTheComputedRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc := newTestScheduler(t, "")
+
+	// Started ten minutes ago, so it's still due for most of its hour.
+	startAt := time.Now().UTC().Add(-10 * time.Minute).Truncate(time.Second)
+
+	schedule, err := sc.Create(&scheduler.Schedule{StartAt: startAt, Duration: "1h", Payload: json.RawMessage(`{}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, startAt, *schedule.NextRun)
+
+	// Editing it before it fired computes the same next run Create did.
+	updated, err := sc.Update(schedule.ID, &scheduler.Schedule{StartAt: startAt, Duration: "1h", Payload: json.RawMessage(`{"record_url":"https://example.com"}`)})
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, updated.NextRun) {
+		assert.Equal(t, startAt, *updated.NextRun)
+	}
+
+	l := &launcher{}
+	sc.SetLauncher(l.launch)
+
+	go sc.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		s, _ := sc.Get(schedule.ID)
+		return len(s.Runs) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Editing it once it fired doesn't launch the run again.
+	updated, err = sc.Update(schedule.ID, &scheduler.Schedule{StartAt: startAt, Duration: "2h", Payload: json.RawMessage(`{}`)})
+	assert.NoError(t, err)
+	assert.Nil(t, updated.NextRun)
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Len(t, l.launched(), 1)
+}
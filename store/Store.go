@@ -10,9 +10,19 @@ import (
 
 var store *AppStore
 
+// maxFinished is the number of stopped recordings whose final status is kept for querying.
+const maxFinished = 1000
+
 type AppStore struct {
 	mu        sync.RWMutex
 	Pipelines map[string]*pipeline.Pipeline
+	Finished  map[string]pipeline.Status
+
+	// finishedOrder holds the IDs of Finished, oldest first.
+	finishedOrder []string
+
+	// starting counts the slots reserved for pipelines that are still starting.
+	starting int
 }
 
 // GetStore retrieves the store from the context, if ctx is nil it returns the global store.
@@ -34,6 +44,7 @@ func NewStore() *AppStore {
 
 	store = &AppStore{
 		Pipelines: make(map[string]*pipeline.Pipeline),
+		Finished:  make(map[string]pipeline.Status),
 	}
 
 	return store
@@ -76,3 +87,87 @@ func (s *AppStore) ListPipelines() map[string]*pipeline.Pipeline {
 
 	return pipelines
 }
+
+// Count returns the number of recordings in the store, including the ones still starting.
+func (s *AppStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.Pipelines) + s.starting
+}
+
+// Reserve takes a slot for a pipeline about to start, false when max pipelines are running or starting.
+// max <= 0 means no limit. The slot is released with Release once the pipeline is in the store, or failed to start.
+func (s *AppStore) Reserve(max int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if max > 0 && len(s.Pipelines)+s.starting >= max {
+		return false
+	}
+
+	s.starting++
+
+	return true
+}
+
+// Release frees a slot taken with Reserve.
+func (s *AppStore) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.starting > 0 {
+		s.starting--
+	}
+}
+
+// AddFinished keeps the final status of a stopped recording, dropping the oldest beyond maxFinished.
+func (s *AppStore) AddFinished(status pipeline.Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Finished[status.Id]; !ok {
+		s.finishedOrder = append(s.finishedOrder, status.Id)
+	}
+
+	s.Finished[status.Id] = status
+
+	for len(s.finishedOrder) > maxFinished {
+		delete(s.Finished, s.finishedOrder[0])
+		s.finishedOrder = s.finishedOrder[1:]
+	}
+}
+
+// GetFinished retrieves the final status of a stopped recording.
+func (s *AppStore) GetFinished(id string) (pipeline.Status, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status, ok := s.Finished[id]
+	return status, ok
+}
+
+// AddArtifacts adds the URLs of files uploaded next to a stopped recording to its final status.
+func (s *AppStore) AddArtifacts(id string, artifacts map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.Finished[id]
+
+	if !ok {
+		return
+	}
+
+	merged := make(map[string]string, len(status.Artifacts)+len(artifacts))
+
+	for name, url := range status.Artifacts {
+		merged[name] = url
+	}
+
+	for name, url := range artifacts {
+		merged[name] = url
+	}
+
+	status.Artifacts = merged
+	s.Finished[id] = status
+}
@@ -0,0 +1,47 @@
+package store_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OmGuptaIND/pipeline"
+	"github.com/OmGuptaIND/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserve(t *testing.T) {
+	s := store.NewStore()
+
+	assert.True(t, s.Reserve(2))
+	assert.True(t, s.Reserve(2))
+
+	// Pipelines still starting count against the limit, and for whoever waits on Count.
+	assert.False(t, s.Reserve(2))
+	assert.Equal(t, 2, s.Count())
+
+	s.Release()
+
+	assert.Equal(t, 1, s.Count())
+	assert.True(t, s.Reserve(2))
+
+	// No limit.
+	assert.True(t, s.Reserve(0))
+	assert.Equal(t, 3, s.Count())
+}
+
+func TestAddFinishedKeepsTheLatest(t *testing.T) {
+	s := store.NewStore()
+
+	for i := range 1010 {
+		s.AddFinished(pipeline.Status{Id: fmt.Sprintf("finished_%d", i)})
+	}
+
+	_, ok := s.GetFinished("finished_9")
+	assert.False(t, ok)
+
+	_, ok = s.GetFinished("finished_10")
+	assert.True(t, ok)
+
+	_, ok = s.GetFinished("finished_1009")
+	assert.True(t, ok)
+}
@@ -5,17 +5,22 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/OmGuptaIND/cloud"
 	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/metrics"
 )
 
 type Uploader struct {
 	id     string
 	ctx    context.Context
+	log    *slog.Logger
 	closed atomic.Bool
 	wg     *sync.WaitGroup
 
@@ -25,7 +30,8 @@ type Uploader struct {
 	reader *bufio.Reader
 	client cloud.CloudClient
 
-	partNumber int
+	partNumber    int
+	uploadedBytes atomic.Int64
 
 	completedMtx   *sync.Mutex
 	completedParts []*cloud.CloudUploadPartReponse
@@ -48,6 +54,7 @@ func NewUploader(ctx context.Context, reader *bufio.Reader, recordingId *string)
 
 	uploader := &Uploader{
 		ctx:         uploadCtx,
+		log:         logger.Pipeline(*recordingId, "uploader").With("upload_id", *uploaderId),
 		id:          *uploaderId,
 		recordingId: recordingId,
 		storagePath: storagePath,
@@ -103,12 +110,12 @@ func (u *Uploader) addCompletedPart(part *cloud.CloudUploadPartReponse) {
 func (u *Uploader) Start() error {
 	u.wg.Add(1)
 	defer func() {
-		log.Println("Start Uploader is done, Getting out of Start Uploader")
+		u.log.Info("Start Uploader is done, Getting out of Start Uploader")
 		u.wg.Done()
 	}()
 
 	if u.recordingId == nil {
-		log.Println("No recording found to upload.")
+		u.log.Error("No recording found to upload")
 		return fmt.Errorf("no recording found to upload: %s", u.GetID())
 	}
 
@@ -124,9 +131,10 @@ func (u *Uploader) Start() error {
 		bytesRead += n
 
 		if bytesRead >= int(config.MAX_BUFFER_SIZE) || (err == io.EOF && bytesRead > 0) {
-			log.Println("New Part Started", u.partNumber, " Size", bytesRead, " Req_Size", config.MAX_BUFFER_SIZE)
+			u.log.Info("New Part Started", "part_number", u.partNumber, "size", bytesRead, "req_size", config.MAX_BUFFER_SIZE)
 			tempBuffer := make([]byte, bytesRead)
 			copy(tempBuffer, u.buffer[:bytesRead])
+			metrics.UploadInflightBytes.Add(float64(len(tempBuffer)))
 
 			partInput := &cloud.CloudUploadPartInput{
 				UploadId:    u.GetID(),
@@ -138,14 +146,27 @@ func (u *Uploader) Start() error {
 			u.wg.Add(1)
 			go func() {
 				defer u.wg.Done()
+				defer metrics.UploadInflightBytes.Add(-float64(len(*partInput.Buffer)))
+
+				start := time.Now()
 				part, err := u.uploadPart(partInput)
 
 				if err != nil {
-					log.Println("Failed to upload part", partInput.PartNumber, err)
+					metrics.UploadPartFailures.Inc()
+					u.log.Error("Failed to upload part", "part_number", partInput.PartNumber, "error", err)
+					u.bus().Emit(*u.recordingId, events.PipelineError, map[string]any{
+						"component":   "uploader",
+						"part_number": partInput.PartNumber,
+						"error":       err.Error(),
+					})
 					return
 				}
 
+				metrics.UploadPartDuration.Observe(time.Since(start).Seconds())
+				metrics.UploadedBytes.Add(float64(len(*partInput.Buffer)))
+
 				u.addCompletedPart(part)
+				u.emitProgress(partInput)
 			}()
 
 			u.partNumber++
@@ -153,7 +174,7 @@ func (u *Uploader) Start() error {
 		}
 
 		if err == io.EOF {
-			log.Println("EOF reached")
+			u.log.Info("EOF reached")
 			break
 		}
 	}
@@ -164,10 +185,10 @@ func (u *Uploader) Start() error {
 // Stop stops the Uploader, buffers everything from the recording onto a temp buffer after which start a different goroutine to upload the last part.
 // After all parts are uploaded, it completes the upload.
 func (u *Uploader) Stop() (*cloud.CloudUploadPartCompleted, error) {
-	log.Println("Stopping uploader...")
+	u.log.Info("Stopping uploader")
 
 	if u.closed.Load() {
-		log.Println("Uploader is already closed")
+		u.log.Info("Uploader is already closed")
 		return nil, fmt.Errorf("uploader is already closed")
 	}
 
@@ -176,7 +197,7 @@ func (u *Uploader) Stop() (*cloud.CloudUploadPartCompleted, error) {
 	resp, err := u.completeUpload()
 
 	if err != nil {
-		log.Println("Failed to complete upload", err)
+		u.log.Error("Failed to complete upload", "error", err)
 	}
 
 	u.closed.Store(true)
@@ -184,9 +205,30 @@ func (u *Uploader) Stop() (*cloud.CloudUploadPartCompleted, error) {
 	return resp, nil
 }
 
+// emitProgress publishes the upload progress after a part is uploaded.
+func (u *Uploader) emitProgress(input *cloud.CloudUploadPartInput) {
+	uploaded := u.uploadedBytes.Add(int64(len(*input.Buffer)))
+
+	u.completedMtx.Lock()
+	completed := len(u.completedParts)
+	u.completedMtx.Unlock()
+
+	u.bus().Emit(*u.recordingId, events.UploadProgress, map[string]any{
+		"part_number":     input.PartNumber,
+		"part_bytes":      len(*input.Buffer),
+		"uploaded_bytes":  uploaded,
+		"completed_parts": completed,
+	})
+}
+
+// bus returns the event bus of the Uploader, nil if there is none.
+func (u *Uploader) bus() *events.Bus {
+	return events.GetBus(&u.ctx)
+}
+
 // completeUpload completes the upload.
 func (u *Uploader) completeUpload() (*cloud.CloudUploadPartCompleted, error) {
-	log.Println("Completing upload...", len(u.completedParts))
+	u.log.Info("Completing upload", "completed_parts", len(u.completedParts))
 
 	resp, err := u.client.CompletePartUpload(&cloud.CloudUploadPartInput{
 		UploadId:    u.GetID(),
@@ -198,7 +240,7 @@ func (u *Uploader) completeUpload() (*cloud.CloudUploadPartCompleted, error) {
 		return nil, fmt.Errorf("failed to complete multipart upload: %v", err)
 	}
 
-	log.Println("Multipart upload completed", u.GetID(), " Completed Parts Count", len(u.completedParts))
+	u.log.Info("Multipart upload completed", "completed_parts", len(u.completedParts))
 
 	return &cloud.CloudUploadPartCompleted{
 		Recording_Url: resp.Recording_Url,
@@ -207,7 +249,7 @@ func (u *Uploader) completeUpload() (*cloud.CloudUploadPartCompleted, error) {
 
 // Upload uploads the recording to the cloud.
 func (u *Uploader) uploadPart(input *cloud.CloudUploadPartInput) (*cloud.CloudUploadPartReponse, error) {
-	log.Println("Uploading part to cloud...", input.PartNumber)
+	u.log.Info("Uploading part to cloud", "part_number", input.PartNumber)
 
 	partResp, err := u.client.UploadPart(input)
 
@@ -215,7 +257,7 @@ func (u *Uploader) uploadPart(input *cloud.CloudUploadPartInput) (*cloud.CloudUp
 		return nil, err
 	}
 
-	log.Println("Part uploaded successfully", input.PartNumber, " ETag", partResp.ETag)
+	u.log.Info("Part uploaded successfully", "part_number", input.PartNumber, "etag", partResp.ETag)
 
 	return partResp, nil
 }
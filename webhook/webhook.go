@@ -0,0 +1,447 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OmGuptaIND/config"
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/logger"
+	"github.com/OmGuptaIND/pkg"
+	"github.com/google/uuid"
+)
+
+// maxFinished is the number of delivered or failed deliveries kept for querying.
+const maxFinished = 1000
+
+const (
+	HeaderEvent     = "X-Recorder-Event"
+	HeaderDelivery  = "X-Recorder-Delivery"
+	HeaderSignature = "X-Recorder-Signature"
+	HeaderTimestamp = "X-Recorder-Timestamp"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusFailed    Status = "failed"
+)
+
+// notifyEvents are the pipeline events sent to webhooks.
+var notifyEvents = map[events.Type]bool{
+	events.PipelineStarted:    true,
+	events.PipelineFailed:     true,
+	events.PipelineStopped:    true,
+	events.UploadCompleted:    true,
+	events.StreamDisconnected: true,
+	events.StreamReconnected:  true,
+	events.QualityAlert:       true,
+	events.QualityRecovered:   true,
+	events.BrowserRecovery:    true,
+	// Post-processing finishes after pipeline.stopped, so only the node-wide webhook gets it.
+	events.PostprocessCompleted: true,
+	events.PostprocessFailed:    true,
+}
+
+// Target is an endpoint events are delivered to, payloads are signed with Secret when set.
+type Target struct {
+	URL    string
+	Secret string
+}
+
+type Delivery struct {
+	ID         string          `json:"id"`
+	EventID    string          `json:"event_id"`
+	Event      events.Type     `json:"event"`
+	PipelineID string          `json:"pipeline_id"`
+	URL        string          `json:"url"`
+	Payload    json.RawMessage `json:"payload"`
+	Signature  string          `json:"signature,omitempty"`
+	// Timestamp is the unix time the delivery was signed at, retries keep it.
+	Timestamp     int64      `json:"timestamp,omitempty"`
+	Status        Status     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+
+	inFlight bool
+}
+
+type NotifierOptions struct {
+	// Path is the file the outbox is persisted to.
+	Path string
+	// Global receives the events of every pipeline when its URL is set.
+	Global       Target
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	Client       *http.Client
+	PollInterval time.Duration
+}
+
+// Notifier delivers pipeline events to webhooks through a durable outbox, retrying with backoff.
+type Notifier struct {
+	mu         sync.Mutex
+	deliveries map[string]*Delivery
+	targets    map[string]Target
+	wake       chan struct{}
+	// dirty marks the outbox changed since it was last written.
+	dirty bool
+	log   *slog.Logger
+
+	*NotifierOptions
+}
+
+// GetNotifier retrieves the webhook notifier from the context.
+func GetNotifier(ctx *context.Context) *Notifier {
+	n, _ := (*ctx).Value(config.WebhookKey).(*Notifier)
+
+	return n
+}
+
+// NewNotifier creates a new Notifier, restoring the outbox persisted at opts.Path.
+func NewNotifier(opts *NotifierOptions) (*Notifier, error) {
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 10
+	}
+
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = 5 * time.Second
+	}
+
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = 10 * time.Minute
+	}
+
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if opts.PollInterval == 0 {
+		opts.PollInterval = time.Second
+	}
+
+	n := &Notifier{
+		deliveries:      make(map[string]*Delivery),
+		targets:         make(map[string]Target),
+		wake:            make(chan struct{}, 1),
+		log:             logger.Component("webhook"),
+		NotifierOptions: opts,
+	}
+
+	var deliveries []*Delivery
+
+	if err := pkg.ReadJSONFile(opts.Path, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to load webhook outbox: %w", err)
+	}
+
+	for _, d := range deliveries {
+		n.deliveries[d.ID] = d
+	}
+
+	n.log.Info("Webhook outbox loaded", "deliveries", len(n.deliveries), "path", opts.Path)
+
+	return n, nil
+}
+
+// Sign returns the signature header value of a payload sent at timestamp: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">.
+// The timestamp is covered so receivers can reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Register sends the events of a pipeline to target, on top of the global target.
+// The target is dropped once the pipeline has stopped or failed.
+func (n *Notifier) Register(pipelineID string, target Target) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.targets[pipelineID] = target
+}
+
+// Run subscribes to the bus and delivers events until the context is done.
+// Events are added to the outbox as they are published so none is dropped, the outbox is written once per round.
+func (n *Notifier) Run(ctx context.Context, bus *events.Bus) {
+	sub := bus.Handle(func(e events.Event) bool {
+		return notifyEvents[e.Type]
+	}, n.enqueue)
+	defer sub.Close()
+
+	ticker := time.NewTicker(n.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.flush()
+			n.log.Info("Webhook notifier stopped")
+			return
+		case <-n.wake:
+		case <-ticker.C:
+		}
+
+		n.deliverDue()
+		n.flush()
+	}
+}
+
+// enqueue adds a delivery to the outbox for every target of the event.
+func (n *Notifier) enqueue(e events.Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	targets := make([]Target, 0, 2)
+
+	if n.Global.URL != "" {
+		targets = append(targets, n.Global)
+	}
+
+	if target, ok := n.targets[e.PipelineID]; ok && target.URL != "" {
+		targets = append(targets, target)
+	}
+
+	if e.Type == events.PipelineStopped || e.Type == events.PipelineFailed {
+		delete(n.targets, e.PipelineID)
+	}
+
+	if len(targets) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(e)
+
+	if err != nil {
+		n.log.Error("Failed to encode webhook payload", "event", e.Type, "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	for _, target := range targets {
+		d := &Delivery{
+			ID:            fmt.Sprintf("delivery_%s", uuid.New().String()),
+			EventID:       e.ID,
+			Event:         e.Type,
+			PipelineID:    e.PipelineID,
+			URL:           target.URL,
+			Payload:       payload,
+			Status:        StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		if target.Secret != "" {
+			d.Timestamp = now.Unix()
+			d.Signature = Sign(target.Secret, d.Timestamp, payload)
+		}
+
+		n.deliveries[d.ID] = d
+	}
+
+	n.dirty = true
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue attempts every pending delivery whose retry time has come.
+func (n *Notifier) deliverDue() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+
+	for _, d := range n.deliveries {
+		if d.Status != StatusPending || d.inFlight || d.NextAttemptAt.After(now) {
+			continue
+		}
+
+		d.inFlight = true
+		attempt := *d
+
+		go n.attempt(&attempt)
+	}
+}
+
+// attempt sends a delivery once and records the outcome.
+func (n *Notifier) attempt(d *Delivery) {
+	code, err := n.send(d)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delivery, ok := n.deliveries[d.ID]
+
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+
+	delivery.inFlight = false
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.UpdatedAt = now
+
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= n.MaxAttempts:
+		n.log.Error("Webhook delivery failed for good", "delivery_id", delivery.ID, "event", delivery.Event, logger.PipelineKey, delivery.PipelineID, "error", err)
+		delivery.Status = StatusFailed
+		delivery.LastError = err.Error()
+	default:
+		backoff := n.RetryBackoff << min(delivery.Attempts-1, 20)
+		n.log.Warn("Webhook delivery failed, retrying", "delivery_id", delivery.ID, "event", delivery.Event, "in", min(backoff, n.MaxBackoff), "error", err)
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(min(backoff, n.MaxBackoff))
+	}
+
+	n.pruneLocked()
+	n.dirty = true
+}
+
+// send posts the delivery payload to its URL, any 2xx response counts as delivered.
+func (n *Notifier) send(d *Delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderDelivery, d.ID)
+
+	if d.Signature != "" {
+		req.Header.Set(HeaderSignature, d.Signature)
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(d.Timestamp, 10))
+	}
+
+	resp, err := n.Client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Get returns a copy of the delivery with the given ID.
+func (n *Notifier) Get(id string) (*Delivery, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	d, ok := n.deliveries[id]
+
+	if !ok {
+		return nil, false
+	}
+
+	delivery := *d
+
+	return &delivery, true
+}
+
+// List returns copies of the deliveries, oldest first, filtered by pipeline when pipelineID is set.
+func (n *Notifier) List(pipelineID string) []*Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	deliveries := make([]*Delivery, 0)
+
+	for _, d := range n.deliveries {
+		if pipelineID != "" && d.PipelineID != pipelineID {
+			continue
+		}
+
+		delivery := *d
+		deliveries = append(deliveries, &delivery)
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+
+	return deliveries
+}
+
+// pruneLocked drops the oldest finished deliveries beyond maxFinished.
+func (n *Notifier) pruneLocked() {
+	finished := make([]*Delivery, 0)
+
+	for _, d := range n.deliveries {
+		if d.Status != StatusPending {
+			finished = append(finished, d)
+		}
+	}
+
+	if len(finished) <= maxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].UpdatedAt.Before(finished[j].UpdatedAt)
+	})
+
+	for _, d := range finished[:len(finished)-maxFinished] {
+		delete(n.deliveries, d.ID)
+	}
+}
+
+// flush writes the outbox to disk when it changed since the last write.
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.dirty || n.Path == "" {
+		return
+	}
+
+	n.dirty = false
+
+	deliveries := make([]*Delivery, 0, len(n.deliveries))
+
+	for _, d := range n.deliveries {
+		deliveries = append(deliveries, d)
+	}
+
+	if err := pkg.WriteJSONFile(n.Path, deliveries); err != nil {
+		n.log.Error("Failed to persist webhook outbox", "error", err)
+		n.dirty = true
+	}
+}
@@ -0,0 +1,179 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OmGuptaIND/events"
+	"github.com/OmGuptaIND/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+type receiver struct {
+	mtx      sync.Mutex
+	failures int
+	received []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.received = append(r.received, req)
+	r.bodies = append(r.bodies, body)
+}
+
+func (r *receiver) count() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return len(r.received)
+}
+
+func TestNotifierDeliversSignedEventsWithRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	global := &receiver{failures: 2}
+	globalServer := httptest.NewServer(global)
+	defer globalServer.Close()
+
+	perPipeline := &receiver{}
+	perPipelineServer := httptest.NewServer(perPipeline)
+	defer perPipelineServer.Close()
+
+	notifier, err := webhook.NewNotifier(&webhook.NotifierOptions{
+		Path:         filepath.Join(t.TempDir(), "webhooks.json"),
+		Global:       webhook.Target{URL: globalServer.URL, Secret: "global-secret"},
+		RetryBackoff: 10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	bus := events.NewBus()
+	go notifier.Run(ctx, bus)
+
+	// Give Run a moment to subscribe before publishing.
+	time.Sleep(20 * time.Millisecond)
+
+	notifier.Register("pipeline_1", webhook.Target{URL: perPipelineServer.URL})
+
+	bus.Emit("pipeline_1", events.PipelineStopped, map[string]any{"reason": "requested"})
+	// Only lifecycle events are delivered.
+	bus.Emit("pipeline_1", "encoder.stats", nil)
+
+	assert.Eventually(t, func() bool {
+		return global.count() == 1 && perPipeline.count() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	global.mtx.Lock()
+	req, body := global.received[0], global.bodies[0]
+	global.mtx.Unlock()
+
+	assert.Equal(t, string(events.PipelineStopped), req.Header.Get(webhook.HeaderEvent))
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().Unix(), timestamp, 5)
+	assert.Equal(t, webhook.Sign("global-secret", timestamp, body), req.Header.Get(webhook.HeaderSignature))
+
+	var event events.Event
+	assert.Nil(t, json.Unmarshal(body, &event))
+	assert.Equal(t, "pipeline_1", event.PipelineID)
+	assert.Equal(t, "requested", event.Data["reason"])
+
+	perPipeline.mtx.Lock()
+	assert.Empty(t, perPipeline.received[0].Header.Get(webhook.HeaderSignature))
+	assert.Empty(t, perPipeline.received[0].Header.Get(webhook.HeaderTimestamp))
+	perPipeline.mtx.Unlock()
+
+	// The receivers count a request before the notifier records its outcome.
+	assert.Eventually(t, func() bool {
+		for _, d := range notifier.List("pipeline_1") {
+			if d.Status != webhook.StatusDelivered {
+				return false
+			}
+		}
+
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	deliveries := notifier.List("pipeline_1")
+	assert.Len(t, deliveries, 2)
+
+	for _, d := range deliveries {
+		assert.Equal(t, webhook.StatusDelivered, d.Status)
+
+		if d.URL == globalServer.URL {
+			assert.Equal(t, 3, d.Attempts)
+		}
+	}
+
+	// A stopped pipeline's own target is dropped, only the global one keeps receiving.
+	bus.Emit("pipeline_1", events.PipelineStarted, nil)
+
+	assert.Eventually(t, func() bool {
+		return global.count() == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, perPipeline.count())
+}
+
+func TestNotifierKeepsEveryEventOfABurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+
+	// Nothing is delivered, so the deliveries stay in the outbox.
+	notifier, err := webhook.NewNotifier(&webhook.NotifierOptions{
+		Path:         path,
+		Global:       webhook.Target{URL: "http://127.0.0.1:1"},
+		RetryBackoff: time.Hour,
+		PollInterval: 10 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	bus := events.NewBus()
+	go notifier.Run(ctx, bus)
+
+	time.Sleep(20 * time.Millisecond)
+
+	// More than any subscription buffer would hold.
+	for range 2000 {
+		bus.Emit("pipeline_1", events.PipelineStarted, nil)
+	}
+
+	assert.Len(t, notifier.List("pipeline_1"), 2000)
+
+	assert.Eventually(t, func() bool {
+		restored, err := webhook.NewNotifier(&webhook.NotifierOptions{Path: path})
+		return err == nil && len(restored.List("pipeline_1")) == 2000
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestSignCoversTheTimestamp(t *testing.T) {
+	body := []byte(`{"type":"pipeline.stopped"}`)
+
+	// HMAC-SHA256 of "1700000000.{...}" with the secret, as a receiver computes it.
+	assert.Equal(t, "sha256=9dccf09097460ff513585894f9d4d98550622eb8cb103f3954a07609187d4554", webhook.Sign("secret", 1700000000, body))
+
+	// A replay with another timestamp doesn't match.
+	assert.NotEqual(t, webhook.Sign("secret", 1700000000, body), webhook.Sign("secret", 1700000300, body))
+}